@@ -0,0 +1,251 @@
+package bitfield
+
+import "sync"
+
+// Strategy selects the free run AllocN allocates from.
+type Strategy int
+
+const (
+	// FirstFit allocates from the lowest free run that is large enough.
+	FirstFit Strategy = iota
+	// BestFit allocates from the smallest free run that is large enough,
+	// the lowest of them if there are several.
+	BestFit
+)
+
+// Allocator hands out ids or slots in [0, Len()), lowest first. It is
+// built on a Hierarchical, so finding a free slot is O(log64 n). Unlike
+// BitField positions are not wrapped around: ids outside [0, Len()) return
+// ErrOutOfRange. It is not safe for concurrent use, see SyncAllocator.
+type Allocator struct {
+	h        *Hierarchical
+	strategy Strategy
+}
+
+// NewAllocator creates an allocator of size slots, all free.
+func NewAllocator(size int) *Allocator {
+	return &Allocator{h: NewHierarchical(size)}
+}
+
+// SetStrategy sets the strategy of AllocN, FirstFit by default.
+func (a *Allocator) SetStrategy(s Strategy) *Allocator {
+	a.strategy = s
+	return a
+}
+
+// Len returns the number of slots.
+func (a *Allocator) Len() int {
+	return a.h.Len()
+}
+
+// Available returns the number of free slots.
+func (a *Allocator) Available() int {
+	return a.h.Len() - a.h.OnesCount()
+}
+
+// IsAllocated tells if slot id is allocated. Returns false for ids out of
+// range.
+func (a *Allocator) IsAllocated(id int) bool {
+	return id >= 0 && id < a.h.Len() && a.h.Get(id)
+}
+
+// Alloc allocates the lowest free slot. Returns ErrExhausted if there is
+// none.
+func (a *Allocator) Alloc() (int, error) {
+	id, ok := a.h.FindFirstClear()
+	if !ok {
+		return -1, ErrExhausted
+	}
+	a.h.Set(id)
+	return id, nil
+}
+
+// AllocN allocates n contiguous slots according to the strategy, returns
+// the first one. Returns ErrExhausted if no free run is large enough.
+func (a *Allocator) AllocN(n int) (int, error) {
+	if a.strategy == BestFit {
+		return a.allocBest(n)
+	}
+	return a.AllocAligned(n, 1)
+}
+
+// AllocAligned allocates the lowest n contiguous slots starting at a
+// multiple of align, returns the first one. Returns ErrExhausted if there
+// are none.
+func (a *Allocator) AllocAligned(n, align int) (int, error) {
+	if n < 1 || align < 1 {
+		return -1, ErrOutOfRange
+	}
+	for from := 0; ; {
+		start, ok := a.h.NextClear(from)
+		if !ok {
+			return -1, ErrExhausted
+		}
+		start = (start + align - 1) / align * align
+		if start+n > a.h.Len() {
+			return -1, ErrExhausted
+		}
+		end, ok := a.h.NextSet(start)
+		if !ok || end >= start+n {
+			a.h.SetRange(start, start+n)
+			return start, nil
+		}
+		from = end
+	}
+}
+
+func (a *Allocator) allocBest(n int) (int, error) {
+	if n < 1 {
+		return -1, ErrOutOfRange
+	}
+	best, bestLen := -1, 0
+	for from := 0; ; {
+		start, ok := a.h.NextClear(from)
+		if !ok {
+			break
+		}
+		end, ok := a.h.NextSet(start)
+		if !ok {
+			end = a.h.Len()
+		}
+		if size := end - start; size >= n && (best < 0 || size < bestLen) {
+			best, bestLen = start, size
+			if size == n {
+				break
+			}
+		}
+		from = end
+	}
+	if best < 0 {
+		return -1, ErrExhausted
+	}
+	a.h.SetRange(best, best+n)
+	return best, nil
+}
+
+// Free frees slot id. Returns ErrNotAllocated if it is free.
+func (a *Allocator) Free(id int) error {
+	return a.FreeRange(id, id+1)
+}
+
+// FreeRange frees the slots in the range [start, end). Returns
+// ErrNotAllocated and frees nothing if any of them is free.
+func (a *Allocator) FreeRange(start, end int) error {
+	if start < 0 || end > a.h.Len() || start > end {
+		return ErrOutOfRange
+	}
+	if pos, ok := a.h.NextClear(start); ok && pos < end {
+		return ErrNotAllocated
+	}
+	a.h.ClearRange(start, end)
+	return nil
+}
+
+// Reserve allocates the given slots. Returns ErrInUse and allocates nothing
+// if any of them is allocated.
+func (a *Allocator) Reserve(ids ...int) error {
+	for _, id := range ids {
+		if id < 0 || id >= a.h.Len() {
+			return ErrOutOfRange
+		}
+		if a.h.Get(id) {
+			return ErrInUse
+		}
+	}
+	for _, id := range ids {
+		a.h.Set(id)
+	}
+	return nil
+}
+
+// BitField returns the allocated slots as a bitfield.
+func (a *Allocator) BitField() *BitField {
+	return a.h.BitField()
+}
+
+// SyncAllocator is an Allocator safe for concurrent use.
+type SyncAllocator struct {
+	mu sync.Mutex
+	a  *Allocator
+}
+
+// NewSyncAllocator creates a concurrency-safe allocator of size slots.
+func NewSyncAllocator(size int) *SyncAllocator {
+	return &SyncAllocator{a: NewAllocator(size)}
+}
+
+// SetStrategy sets the strategy of AllocN, FirstFit by default.
+func (s *SyncAllocator) SetStrategy(strategy Strategy) *SyncAllocator {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.a.SetStrategy(strategy)
+	return s
+}
+
+// Len returns the number of slots.
+func (s *SyncAllocator) Len() int {
+	return s.a.Len()
+}
+
+// Available returns the number of free slots.
+func (s *SyncAllocator) Available() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.a.Available()
+}
+
+// IsAllocated tells if slot id is allocated.
+func (s *SyncAllocator) IsAllocated(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.a.IsAllocated(id)
+}
+
+// Alloc allocates the lowest free slot, see Allocator.Alloc.
+func (s *SyncAllocator) Alloc() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.a.Alloc()
+}
+
+// AllocN allocates n contiguous slots, see Allocator.AllocN.
+func (s *SyncAllocator) AllocN(n int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.a.AllocN(n)
+}
+
+// AllocAligned allocates n aligned slots, see Allocator.AllocAligned.
+func (s *SyncAllocator) AllocAligned(n, align int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.a.AllocAligned(n, align)
+}
+
+// Free frees slot id, see Allocator.Free.
+func (s *SyncAllocator) Free(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.a.Free(id)
+}
+
+// FreeRange frees the slots in [start, end), see Allocator.FreeRange.
+func (s *SyncAllocator) FreeRange(start, end int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.a.FreeRange(start, end)
+}
+
+// Reserve allocates the given slots, see Allocator.Reserve.
+func (s *SyncAllocator) Reserve(ids ...int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.a.Reserve(ids...)
+}
+
+// BitField returns the allocated slots as a bitfield.
+func (s *SyncAllocator) BitField() *BitField {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.a.BitField()
+}
@@ -0,0 +1,119 @@
+package bitfield_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestAllocator(t *testing.T) {
+	a := NewAllocator(10)
+	assert(t, a.Len(), 10)
+	for i := 0; i < 10; i++ {
+		id, err := a.Alloc()
+		assert(t, err, nil)
+		assert(t, id, i)
+	}
+	_, err := a.Alloc()
+	assert(t, errors.Is(err, ErrExhausted), true)
+	assert(t, a.Available(), 0)
+
+	assert(t, a.Free(3), nil)
+	assert(t, a.Free(3), ErrNotAllocated)
+	assert(t, a.Free(10), ErrOutOfRange)
+	assert(t, a.Free(-1), ErrOutOfRange)
+	assert(t, a.IsAllocated(3), false)
+	assert(t, a.IsAllocated(4), true)
+	id, _ := a.Alloc()
+	assert(t, id, 3)
+
+	assert(t, a.FreeRange(2, 6), nil)
+	assert(t, a.Available(), 4)
+	assert(t, a.FreeRange(1, 3), ErrNotAllocated)
+	assert(t, a.IsAllocated(1), true) // nothing freed
+	assert(t, a.FreeRange(5, 11), ErrOutOfRange)
+
+	assert(t, a.Reserve(2, 3), nil)
+	assert(t, a.Reserve(4, 3), ErrInUse)
+	assert(t, a.IsAllocated(4), false) // nothing reserved
+	assert(t, a.Reserve(11), ErrOutOfRange)
+	assert(t, a.BitField().String(), "1111001111")
+}
+
+func TestAllocatorN(t *testing.T) {
+	// free runs: [2,5) [6,8) [10,16)
+	a := NewAllocator(16)
+	assert(t, a.Reserve(0, 1, 5, 8, 9), nil)
+	id, err := a.AllocN(2)
+	assert(t, err, nil)
+	assert(t, id, 2)
+	_, err = a.AllocN(7)
+	assert(t, err, ErrExhausted)
+	_, err = a.AllocN(0)
+	assert(t, err, ErrOutOfRange)
+
+	a = NewAllocator(16).SetStrategy(BestFit)
+	a.Reserve(0, 1, 5, 8, 9)
+	id, _ = a.AllocN(2)
+	assert(t, id, 6)
+	id, _ = a.AllocN(3)
+	assert(t, id, 2)
+	id, _ = a.AllocN(4)
+	assert(t, id, 10)
+	_, err = a.AllocN(3)
+	assert(t, err, ErrExhausted)
+	assert(t, a.Available(), 2)
+
+	a = NewAllocator(16)
+	a.Reserve(1, 9)
+	id, _ = a.AllocAligned(4, 4)
+	assert(t, id, 4)
+	id, _ = a.AllocAligned(2, 4)
+	assert(t, id, 12)
+	_, err = a.AllocAligned(2, 8)
+	assert(t, err, ErrExhausted)
+	id, _ = a.AllocAligned(1, 8)
+	assert(t, id, 0)
+	_, err = a.AllocAligned(1, 0)
+	assert(t, err, ErrOutOfRange)
+	id, _ = a.AllocAligned(1, 2)
+	assert(t, id, 2)
+}
+
+func TestSyncAllocator(t *testing.T) {
+	const workers, each = 8, 100
+	a := NewSyncAllocator(workers * each).SetStrategy(FirstFit)
+	ids := make(chan int, workers*each)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				id, err := a.Alloc()
+				assert(t, err, nil)
+				ids <- id
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+	seen := New(workers * each)
+	for id := range ids {
+		assert(t, seen.Get(id), false)
+		seen.Mut().Set(id)
+	}
+	assert(t, a.Available(), 0)
+	assert(t, a.Free(5), nil)
+	assert(t, a.IsAllocated(5), false)
+	assert(t, a.Reserve(5), nil)
+	assert(t, a.FreeRange(0, 10), nil)
+	id, _ := a.AllocN(10)
+	assert(t, id, 0)
+	_, err := a.AllocAligned(1, 1)
+	assert(t, err, ErrExhausted)
+	assert(t, a.Len(), workers*each)
+	assert(t, a.BitField().OnesCount(), workers*each)
+}
@@ -0,0 +1,169 @@
+package bitfield
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// atomicStripeWords is the number of words sharing a pair of write counters
+const atomicStripeWords = 8
+
+// atomicStripe counts the writes to a stripe of words: started before the
+// word is written, finished after. They are equal while no write is under way.
+type atomicStripe struct {
+	started  atomic.Uint64
+	finished atomic.Uint64
+}
+
+// AtomicBitField is a fixed size bitfield that is safe for concurrent use.
+// Writers never wait for each other nor for readers: a write is an atomic
+// operation on its 64 bit word between two counter increments.
+//
+// Positions outside the [0,len) range get the modulo treatment as in BitField.
+type AtomicBitField struct {
+	data    []atomic.Uint64
+	stripes []atomicStripe
+	len     int
+}
+
+// NewAtomic creates a new AtomicBitField of length len.
+// Panics if len<0
+func NewAtomic(len int) *AtomicBitField {
+	if len < 0 {
+		panic(ErrNegativeLength)
+	}
+	words := 1 + len/64
+	return &AtomicBitField{
+		data:    make([]atomic.Uint64, words),
+		stripes: make([]atomicStripe, (words+atomicStripeWords-1)/atomicStripeWords),
+		len:     len,
+	}
+}
+
+// Len returns the number of bits the AtomicBitField holds
+func (a *AtomicBitField) Len() int {
+	return a.len
+}
+
+// locate returns the index and mask of the bit at pos, ok is false if the
+// bitfield is empty.
+func (a *AtomicBitField) locate(pos int) (index int, mask uint64, ok bool) {
+	pos, _ = Modulo.normalize(pos, a.len)
+	if pos < 0 {
+		return 0, 0, false
+	}
+	return pos / 64, 1 << uint(pos%64), true
+}
+
+// write runs f on the word at index, counting it as a write for Snapshot
+func (a *AtomicBitField) write(index int, f func(w *atomic.Uint64) bool) bool {
+	s := &a.stripes[index/atomicStripeWords]
+	s.started.Add(1)
+	defer s.finished.Add(1)
+	return f(&a.data[index])
+}
+
+// Get returns the bit (as a boolean) at position pos
+func (a *AtomicBitField) Get(pos int) bool {
+	index, mask, ok := a.locate(pos)
+	return ok && a.data[index].Load()&mask != 0
+}
+
+// Set sets the bit at position pos
+func (a *AtomicBitField) Set(pos int) {
+	a.TestAndSet(pos)
+}
+
+// Clear clears the bit at position pos
+func (a *AtomicBitField) Clear(pos int) {
+	a.TestAndClear(pos)
+}
+
+// TestAndSet sets the bit at position pos and returns its previous value
+func (a *AtomicBitField) TestAndSet(pos int) bool {
+	index, mask, ok := a.locate(pos)
+	if !ok {
+		return false
+	}
+	return a.write(index, func(w *atomic.Uint64) bool {
+		return w.Or(mask)&mask != 0
+	})
+}
+
+// TestAndClear clears the bit at position pos and returns its previous value
+func (a *AtomicBitField) TestAndClear(pos int) bool {
+	index, mask, ok := a.locate(pos)
+	if !ok {
+		return false
+	}
+	return a.write(index, func(w *atomic.Uint64) bool {
+		return w.And(^mask)&mask != 0
+	})
+}
+
+// Word returns the word at index: bit i of it is position index*64+i.
+// Panics if index is outside [0, (Len()+63)/64)
+func (a *AtomicBitField) Word(index int) uint64 {
+	return a.data[index].Load()
+}
+
+// CompareAndSwapWord sets the word at index to new if it holds old, and tells
+// if it did so. Bits of new beyond Len() are cleared.
+// Panics if index is outside [0, (Len()+63)/64)
+func (a *AtomicBitField) CompareAndSwapWord(index int, old, new uint64) bool {
+	if rest := a.len - index*64; rest < 64 {
+		new &= uint64(rangeMask(0, rest))
+	}
+	return a.write(index, func(w *atomic.Uint64) bool {
+		return w.CompareAndSwap(old, new)
+	})
+}
+
+// OnesCount returns the number of bits set. Under concurrent writes the
+// words are counted one by one, use Snapshot().OnesCount() for a consistent
+// count.
+func (a *AtomicBitField) OnesCount() int {
+	count := 0
+	for i := range a.data {
+		count += BitField64(a.data[i].Load()).OnesCount()
+	}
+	return count
+}
+
+// snapshotHook is called by Snapshot after loading each word, for tests
+var snapshotHook func(index int)
+
+// Snapshot returns a copy of the bitfield as it was at one point in time.
+// It copies the words while no write is under way and retries if a write
+// started meanwhile, so it may be delayed by a steady stream of writes.
+func (a *AtomicBitField) Snapshot() *BitField {
+	ret := New(a.len)
+	started := make([]uint64, len(a.stripes))
+	for !a.collect(ret.data, started) {
+		runtime.Gosched()
+	}
+	return ret
+}
+
+// collect copies the words to dst, tells if no write was under way or
+// started while copying. started is scratch space of a counter per stripe.
+func (a *AtomicBitField) collect(dst []BitField64, started []uint64) bool {
+	for i := range a.stripes {
+		started[i] = a.stripes[i].started.Load()
+		if a.stripes[i].finished.Load() != started[i] {
+			return false
+		}
+	}
+	for i := range a.data {
+		dst[i] = BitField64(a.data[i].Load())
+		if snapshotHook != nil {
+			snapshotHook(i)
+		}
+	}
+	for i := range a.stripes {
+		if a.stripes[i].started.Load() != started[i] {
+			return false
+		}
+	}
+	return true
+}
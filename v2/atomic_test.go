@@ -0,0 +1,82 @@
+package bitfield_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestAtomic(t *testing.T) {
+	a := NewAtomic(130)
+	assert(t, a.Len(), 130)
+	a.Set(0)
+	a.Set(-1)
+	assert(t, a.Get(0), true)
+	assert(t, a.Get(129), true)
+	assert(t, a.Get(130), true)
+	assert(t, a.TestAndSet(5), false)
+	assert(t, a.TestAndSet(5), true)
+	assert(t, a.TestAndClear(5), true)
+	assert(t, a.TestAndClear(5), false)
+	a.Clear(0)
+	assert(t, a.OnesCount(), 1)
+
+	assert(t, a.CompareAndSwapWord(2, 0, 1), false)
+	assert(t, a.CompareAndSwapWord(2, 2, ^uint64(0)), true)
+	assert(t, a.Word(2), uint64(3))
+	assert(t, a.OnesCount(), 2)
+	assert(t, a.Snapshot().Equal(New(130).Set(128, 129)), true)
+
+	e := NewAtomic(0)
+	e.Set(0)
+	assert(t, e.Get(0), false)
+	assert(t, e.TestAndClear(0), false)
+	assert(t, e.Snapshot().Len(), 0)
+	if !doesPanic(func() { NewAtomic(-1) }) {
+		t.Error("should panic")
+	}
+}
+
+func TestAtomicConcurrent(t *testing.T) {
+	const size, workers = 10000, 8
+	a := NewAtomic(size)
+	var won atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < size; i++ {
+				if !a.TestAndSet(i) {
+					won.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert(t, won.Load(), int64(size))
+	assert(t, a.OnesCount(), size)
+
+	// bits are set in increasing order: a consistent snapshot is a prefix
+	b := NewAtomic(size)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < size; i++ {
+			b.Set(i)
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		s := b.Snapshot()
+		if n := s.OnesCount(); n > 0 && !s.AllInRange(0, n) {
+			t.Fatal("inconsistent snapshot")
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package bitfield
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+)
+
+// Binary format, all integers little-endian:
+//
+//	magic    2 bytes  "BF"
+//	version  1 byte   binaryVersion
+//	flags    1 byte   flagCRC: a CRC32 (IEEE) trailer is present
+//	length   uvarint  number of bits
+//	words    8 bytes each, (length+63)/64 of them
+//	crc      4 bytes  CRC32 of everything before it, if flagCRC is set
+const (
+	binaryMagic   = "BF"
+	binaryVersion = 1
+	flagCRC       = 1 << 0
+
+	// maxBinaryLen is the largest length accepted by UnmarshalBinary, an int
+	// on 32-bit platforms too
+	maxBinaryLen uint64 = min(1<<40, math.MaxInt)
+)
+
+// appendBinary appends the encoding of length bits stored in words to b
+func appendBinary(b []byte, words []BitField64, length int, withCRC bool) []byte {
+	start := len(b)
+	flags := byte(0)
+	if withCRC {
+		flags |= flagCRC
+	}
+	b = append(b, binaryMagic...)
+	b = append(b, binaryVersion, flags)
+	b = binary.AppendUvarint(b, uint64(length))
+	for _, w := range words[:(length+63)/64] {
+		b = binary.LittleEndian.AppendUint64(b, uint64(w))
+	}
+	if withCRC {
+		b = binary.LittleEndian.AppendUint32(b, crc32.ChecksumIEEE(b[start:]))
+	}
+	return b
+}
+
+// decodeBinary decodes data into words of the bit length returned.
+// The returned words have room for 1+len/64 elements.
+func decodeBinary(data []byte) ([]BitField64, int, error) {
+	const headerLen = len(binaryMagic) + 2
+	if len(data) < headerLen || string(data[:len(binaryMagic)]) != binaryMagic {
+		return nil, 0, ErrBadFormat
+	}
+	if data[2] != binaryVersion || data[3]&^flagCRC != 0 {
+		return nil, 0, ErrBadFormat
+	}
+	if data[3]&flagCRC != 0 {
+		if len(data) < headerLen+4 {
+			return nil, 0, ErrBadFormat
+		}
+		end := len(data) - 4
+		if crc32.ChecksumIEEE(data[:end]) != binary.LittleEndian.Uint32(data[end:]) {
+			return nil, 0, ErrChecksum
+		}
+		data = data[:end]
+	}
+	data = data[headerLen:]
+	length, n := binary.Uvarint(data)
+	if n <= 0 || length > maxBinaryLen {
+		return nil, 0, ErrBadFormat
+	}
+	data = data[n:]
+	nWords := int((length + 63) / 64)
+	if len(data) != nWords*8 {
+		return nil, 0, ErrBadFormat
+	}
+	words := make([]BitField64, 1+length/64)
+	for i := 0; i < nWords; i++ {
+		words[i] = BitField64(binary.LittleEndian.Uint64(data[i*8:]))
+	}
+	// bits beyond the length must be zero
+	if rest := length % 64; rest != 0 && words[nWords-1].Shift(-int(rest)) != 0 {
+		return nil, 0, ErrBadFormat
+	}
+	return words, int(length), nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The encoding is protected by a CRC32 checksum.
+func (bf *BitField) MarshalBinary() ([]byte, error) {
+	return bf.AppendBinary(nil)
+}
+
+// AppendBinary implements encoding.BinaryAppender: it appends the encoding
+// of the bitfield to b. The encoding is protected by a CRC32 checksum.
+func (bf *BitField) AppendBinary(b []byte) ([]byte, error) {
+	return appendBinary(b, bf.data, bf.len, true), nil
+}
+
+// AppendBinaryNoCRC is the same as AppendBinary but leaves out the checksum.
+func (bf *BitField) AppendBinaryNoCRC(b []byte) []byte {
+	return appendBinary(b, bf.data, bf.len, false)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces the
+// content and length of bf, the mutable flag and the policy are kept.
+// Returns ErrBadFormat or ErrChecksum if data is corrupt.
+func (bf *BitField) UnmarshalBinary(data []byte) error {
+	words, length, err := decodeBinary(data)
+	if err != nil {
+		return err
+	}
+	bf.data, bf.len = words, length
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The encoding is the same as of a 64 bit long BitField.
+func (bf64 BitField64) MarshalBinary() ([]byte, error) {
+	return bf64.AppendBinary(nil)
+}
+
+// AppendBinary implements encoding.BinaryAppender.
+func (bf64 BitField64) AppendBinary(b []byte) ([]byte, error) {
+	return appendBinary(b, []BitField64{bf64}, 64, true), nil
+}
+
+// AppendBinaryNoCRC is the same as AppendBinary but leaves out the checksum.
+func (bf64 BitField64) AppendBinaryNoCRC(b []byte) []byte {
+	return appendBinary(b, []BitField64{bf64}, 64, false)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// Accepts the encoding of any BitField of 64 bits or less.
+func (bf64 *BitField64) UnmarshalBinary(data []byte) error {
+	words, length, err := decodeBinary(data)
+	if err != nil {
+		return err
+	}
+	if length > 64 {
+		return ErrBadFormat
+	}
+	*bf64 = words[0]
+	return nil
+}
@@ -0,0 +1,82 @@
+package bitfield_test
+
+import (
+	"encoding"
+	"errors"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*BitField)(nil)
+	_ encoding.BinaryUnmarshaler = (*BitField)(nil)
+	_ encoding.BinaryMarshaler   = BitField64(0)
+	_ encoding.BinaryUnmarshaler = (*BitField64)(nil)
+)
+
+func TestBinary(t *testing.T) {
+	for _, size := range []int{0, 1, 63, 64, 65, 200} {
+		a := New(size).Set(0, size/2, -1)
+		data, err := a.MarshalBinary()
+		assert(t, err, nil)
+		b := New(3).Mut()
+		assert(t, b.UnmarshalBinary(data), nil)
+		assert(t, b.Equal(a), true)
+
+		c := New(0)
+		assert(t, c.UnmarshalBinary(a.AppendBinaryNoCRC(nil)), nil)
+		assert(t, c.Equal(a), true)
+	}
+
+	prefix := []byte("xyz")
+	data, _ := New(10).Set(3).AppendBinary(prefix)
+	assert(t, string(data[:3]), "xyz")
+
+	// corruption
+	data, _ = New(100).Set(7).MarshalBinary()
+	bad := append([]byte(nil), data...)
+	bad[10] ^= 1
+	assert(t, errors.Is(New(0).UnmarshalBinary(bad), ErrChecksum), true)
+
+	for _, in := range [][]byte{
+		nil,
+		[]byte("XX\x01\x00\x00"),
+		[]byte("BF\x02\x00\x00"),
+		[]byte("BF\x01\x80\x00"),
+		[]byte("BF\x01\x01\x00"),
+		[]byte("BF\x01\x00\x05"), // missing word
+		[]byte("BF\x01\x00\x05\x00\x00\x00\x00\x00\x00\x00"),         // short word
+		[]byte("BF\x01\x00\x05\x20\x00\x00\x00\x00\x00\x00\x00"),     // bit beyond length
+		[]byte("BF\x01\x00\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01"), // huge length
+		[]byte("BF\x01\x00\x80\x80\x80\x80\x80\x08"),                 // 1<<32 words, 0 as a 32-bit int
+		New(10).AppendBinaryNoCRC(nil)[:4],
+	} {
+		if err := New(0).UnmarshalBinary(in); !errors.Is(err, ErrBadFormat) {
+			t.Errorf("%q: got %v", in, err)
+		}
+	}
+}
+
+func TestBinary64(t *testing.T) {
+	a := New64().SetMul(0, 33, 63)
+	data, err := a.MarshalBinary()
+	assert(t, err, nil)
+	var b BitField64
+	assert(t, b.UnmarshalBinary(data), nil)
+	assert(t, b, a)
+	assert(t, b.UnmarshalBinary(a.AppendBinaryNoCRC(nil)), nil)
+	assert(t, b, a)
+
+	// a BitField64 decodes as a 64 bit BitField and vice versa
+	bf := New(0)
+	assert(t, bf.UnmarshalBinary(data), nil)
+	assert(t, bf.Equal(New(64).Set(0, 33, 63)), true)
+	data, _ = New(10).Set(2).MarshalBinary()
+	assert(t, b.UnmarshalBinary(data), nil)
+	assert(t, b, New64().Set(2))
+
+	data, _ = New(65).MarshalBinary()
+	assert(t, errors.Is(b.UnmarshalBinary(data), ErrBadFormat), true)
+	assert(t, errors.Is(b.UnmarshalBinary(nil), ErrBadFormat), true)
+}
@@ -6,11 +6,17 @@ There are two structs defined: BitField64 is in case you want to store
 */
 package bitfield
 
+import (
+	"slices"
+	"sync/atomic"
+)
+
 // BitField is a flexible size version of BitField64.
 //
 // Most functions are chainable, positions outside the [0,len) range
 // will get the modulo treatment, so Get(len) will return the 0th bit, Get(-1)
-// will return the last bit: Get(len-1)
+// will return the last bit: Get(len-1). This can be changed by creating the
+// bitfield with NewWithPolicy.
 //
 // Most methods do not modify the underlying bitfield but create a new and
 // return that. You can change this behaviour by calling .Mut() method. In this
@@ -20,6 +26,8 @@ type BitField struct {
 	data    []BitField64
 	len     int
 	mutable bool
+	policy  Policy
+	shared  uint32 // accessed atomically: data is shared, see own()
 }
 
 // New creates a new BitField of length len
@@ -30,14 +38,20 @@ func New(len int) *BitField {
 // NewBitField creates a new BitField of length len and returns it.
 // Panics if len<0
 func NewBitField(len int) *BitField {
+	return must(NewChecked(len))
+}
+
+// NewChecked creates a new BitField of length len and returns it.
+// Returns ErrNegativeLength if len<0
+func NewChecked(len int) (*BitField, error) {
 	if len < 0 {
-		panic("len cannot be negative")
+		return nil, ErrNegativeLength
 	}
 	return &BitField{
 		data:    make([]BitField64, 1+len/64),
 		len:     len,
 		mutable: false,
-	}
+	}, nil
 }
 
 // Mut sets the mutable flag. This can reduce number of copying
@@ -50,7 +64,7 @@ func (bf *BitField) Mut() *BitField {
 
 // resize always creates a new bitfield regardless of Mut()
 func (bf *BitField) resize(newLen int) *BitField {
-	ret := NewBitField(newLen)
+	ret := bf.newLike(newLen)
 	if newLen == 0 {
 		return ret
 	}
@@ -68,23 +82,30 @@ func (bf *BitField) resize(newLen int) *BitField {
 // If newLen > Len() the newly added bits will be zeroed.
 // Mutable.
 func (bf *BitField) Resize(newLen int) *BitField {
-	ret := bf.resize(newLen)
-	copy(ret.data, bf.data)
-	if newLen < bf.len {
-		ret.clearEnd()
+	return must(bf.TryResize(newLen))
+}
+
+// TryResize is the same as Resize but returns ErrNegativeLength instead of
+// panicking if newLen<0. Mutable.
+func (bf *BitField) TryResize(newLen int) (*BitField, error) {
+	if newLen < 0 {
+		return nil, ErrNegativeLength
 	}
+	ret := bf.resize(newLen)
 	if bf.mutable {
 		bf.data = ret.data
 		bf.len = ret.len
-		return bf
+		atomic.StoreUint32(&bf.shared, 0)
+		return bf, nil
 	}
-	return ret
+	return ret, nil
 }
 
 // mClone is the mutable-clone: if Mut() set, it returns bf,
 // otherwise bahaves as Clone()
 func (bf *BitField) mClone() *BitField {
 	if bf.mutable {
+		bf.own()
 		return bf
 	}
 	return bf.Clone()
@@ -92,17 +113,43 @@ func (bf *BitField) mClone() *BitField {
 
 // Clone creates a copy of the bitfield and returns it
 func (bf *BitField) Clone() *BitField {
-	ret := New(bf.len)
+	ret := bf.newLike(bf.len)
 	copy(ret.data, bf.data)
 	return ret
 }
 
+// share marks the words of bf as shared with a read-only user of them: a
+// Frozen snapshot or a RankSelect index. Safe to call from several
+// goroutines at once.
+func (bf *BitField) share() {
+	if atomic.LoadUint32(&bf.shared) == 0 {
+		atomic.StoreUint32(&bf.shared, 1)
+	}
+}
+
+// own copies the words of bf if they are shared, so that they can be
+// modified in-place. The readers sharing them keep the old words.
+func (bf *BitField) own() {
+	if atomic.LoadUint32(&bf.shared) != 0 {
+		bf.data = slices.Clone(bf.data)
+		atomic.StoreUint32(&bf.shared, 0)
+	}
+}
+
+// newLike creates a zeroed bitfield of length len with the policy of bf
+func (bf *BitField) newLike(len int) *BitField {
+	ret := NewBitField(len)
+	ret.policy = bf.policy
+	return ret
+}
+
 // Copy copies the content of BitField bf to dest.
 // Returns false if the two bitfields differ in size, true otherwise
 func (bf *BitField) Copy(dest *BitField) bool {
 	if bf.len != dest.len {
 		return false
 	}
+	dest.own()
 	copy(dest.data, bf.data)
 	return true
 }
@@ -112,24 +159,18 @@ func (bf *BitField) Len() int {
 	return bf.len
 }
 
+// posNormalize gives pos the modulo treatment
 func (bf *BitField) posNormalize(pos int) int {
 	if bf.len == 0 {
 		return 0
 	}
-	for pos < 0 {
+	pos %= bf.len
+	if pos < 0 {
 		pos += bf.len
 	}
-	pos %= bf.len
 	return pos
 }
 
-func (bf *BitField) posToOffset(pos int) (index int, offset int) {
-	pos = bf.posNormalize(pos)
-	index = pos / 64
-	offset = pos % 64
-	return
-}
-
 // clearEnd zeroes the bits beyond Len() in-place
 // The underlying BitField64 allocates space in 64bit increments
 // and Len() might be smaller than the space allocated: it needs to be
@@ -147,8 +188,9 @@ func (bf *BitField) clearEnd() *BitField {
 func (bf *BitField) Set(pos ...int) *BitField {
 	ret := bf.mClone()
 	for _, p := range pos {
-		index, offset := bf.posToOffset(p)
-		ret.data[index] = ret.data[index].Set(offset)
+		if index, offset, ok := ret.locate(p, true); ok {
+			ret.data[index] = ret.data[index].Set(offset)
+		}
 	}
 	return ret
 }
@@ -166,8 +208,9 @@ func (bf *BitField) SetAll() *BitField {
 func (bf *BitField) Clear(pos ...int) *BitField {
 	ret := bf.mClone()
 	for _, p := range pos {
-		index, offset := bf.posToOffset(p)
-		ret.data[index] = ret.data[index].Clear(offset)
+		if index, offset, ok := ret.locate(p, false); ok {
+			ret.data[index] = ret.data[index].Clear(offset)
+		}
 	}
 	return ret
 }
@@ -183,16 +226,17 @@ func (bf *BitField) ClearAll() *BitField {
 
 // Get returns the bit (as a boolean) at position pos
 func (bf *BitField) Get(pos int) bool {
-	index, offset := bf.posToOffset(pos)
-	return bf.data[index].Get(offset)
+	index, offset, ok := bf.locate(pos, false)
+	return ok && bf.data[index].Get(offset)
 }
 
 // Flip inverts the bit(s) at position pos. Mutable.
 func (bf *BitField) Flip(pos ...int) *BitField {
 	ret := bf.mClone()
 	for _, p := range pos {
-		index, offset := ret.posToOffset(p)
-		ret.data[index] = ret.data[index].Flip(offset)
+		if index, offset, ok := ret.locate(p, true); ok {
+			ret.data[index] = ret.data[index].Flip(offset)
+		}
 	}
 	return ret
 }
@@ -206,51 +250,376 @@ func (bf *BitField) OnesCount() int {
 	return count
 }
 
-const errLenOther = "Len() of bf and bfOther differ"
+// NextSet returns the position of the first set bit at or after from.
+// Unlike other methods from does not get the modulo treatment: a from below 0
+// starts the search at 0 and false is returned if no set bit is found before
+// Len().
+func (bf *BitField) NextSet(from int) (int, bool) {
+	const n = 64
+	if from >= bf.len {
+		return -1, false
+	}
+	if from < 0 {
+		from = 0
+	}
+	index, offset := from/n, from%n
+	w := bf.data[index].Shift(-offset)
+	if w != 0 {
+		return from + w.TrailingZeros(), true
+	}
+	for index++; index < len(bf.data); index++ {
+		if w = bf.data[index]; w != 0 {
+			return index*n + w.TrailingZeros(), true
+		}
+	}
+	return -1, false
+}
+
+// NextClear returns the position of the first cleared bit at or after from.
+// See NextSet for the handling of from.
+func (bf *BitField) NextClear(from int) (int, bool) {
+	const n = 64
+	if from >= bf.len {
+		return -1, false
+	}
+	if from < 0 {
+		from = 0
+	}
+	index, offset := from/n, from%n
+	pos, ok := bf.data[index].NextClear(offset)
+	for !ok {
+		index++
+		pos, ok = bf.data[index].NextClear(0)
+	}
+	// bits beyond Len() are always zero, so the loop stops at the latest there
+	if pos = index*n + pos; pos < bf.len {
+		return pos, true
+	}
+	return -1, false
+}
+
+// PrevSet returns the position of the last set bit at or before from.
+// from does not get the modulo treatment: a from at or above Len() starts the
+// search at Len()-1 and false is returned if no set bit is found down to 0.
+func (bf *BitField) PrevSet(from int) (int, bool) {
+	const n = 64
+	if from < 0 || bf.len == 0 {
+		return -1, false
+	}
+	if from >= bf.len {
+		from = bf.len - 1
+	}
+	index, offset := from/n, from%n
+	for {
+		if pos, ok := bf.data[index].PrevSet(offset); ok {
+			return index*n + pos, true
+		}
+		if index == 0 {
+			return -1, false
+		}
+		index, offset = index-1, n-1
+	}
+}
+
+// PrevClear returns the position of the last cleared bit at or before from.
+// See PrevSet for the handling of from.
+func (bf *BitField) PrevClear(from int) (int, bool) {
+	const n = 64
+	if from < 0 || bf.len == 0 {
+		return -1, false
+	}
+	if from >= bf.len {
+		from = bf.len - 1
+	}
+	index, offset := from/n, from%n
+	for {
+		if pos, ok := bf.data[index].PrevClear(offset); ok {
+			return index*n + pos, true
+		}
+		if index == 0 {
+			return -1, false
+		}
+		index, offset = index-1, n-1
+	}
+}
+
+// LeadingZeros returns the number of cleared bits before the highest set bit,
+// counting down from position Len()-1. Returns Len() if no bit is set.
+func (bf *BitField) LeadingZeros() int {
+	return bf.len - bf.BitLen()
+}
+
+// TrailingZeros returns the number of cleared bits before the lowest set bit,
+// counting up from position 0. Returns Len() if no bit is set.
+func (bf *BitField) TrailingZeros() int {
+	if pos, ok := bf.NextSet(0); ok {
+		return pos
+	}
+	return bf.len
+}
+
+// BitLen returns the position of the highest set bit plus one,
+// or 0 if no bit is set.
+func (bf *BitField) BitLen() int {
+	if pos, ok := bf.PrevSet(bf.len - 1); ok {
+		return pos + 1
+	}
+	return 0
+}
+
+// forRange calls f for each word overlapping the range [start, end) with the
+// mask of the bits in range. Stops if f returns false.
+// start and end are clamped to [0, Len()].
+func (bf *BitField) forRange(start, end int, f func(index int, mask BitField64) bool) {
+	forRange(bf.len, start, end, f)
+}
+
+// forRange is BitField.forRange for a bitfield of length bits.
+func forRange(length, start, end int, f func(index int, mask BitField64) bool) {
+	const n = 64
+	if start < 0 {
+		start = 0
+	}
+	if end > length {
+		end = length
+	}
+	for index := start / n; start < end; index++ {
+		wordEnd := (index + 1) * n
+		if wordEnd > end {
+			wordEnd = end
+		}
+		if !f(index, rangeMask(start-index*n, wordEnd-index*n)) {
+			return
+		}
+		start = wordEnd
+	}
+}
+
+// SetRange sets the bits in the range [start, end).
+// start and end are clamped to [0, Len()]. Mutable.
+func (bf *BitField) SetRange(start, end int) *BitField {
+	ret := bf.mClone()
+	ret.forRange(start, end, func(index int, mask BitField64) bool {
+		ret.data[index] |= mask
+		return true
+	})
+	return ret
+}
+
+// ClearRange clears the bits in the range [start, end).
+// start and end are clamped to [0, Len()]. Mutable.
+func (bf *BitField) ClearRange(start, end int) *BitField {
+	ret := bf.mClone()
+	ret.forRange(start, end, func(index int, mask BitField64) bool {
+		ret.data[index] &^= mask
+		return true
+	})
+	return ret
+}
+
+// FlipRange inverts the bits in the range [start, end).
+// start and end are clamped to [0, Len()]. Mutable.
+func (bf *BitField) FlipRange(start, end int) *BitField {
+	ret := bf.mClone()
+	ret.forRange(start, end, func(index int, mask BitField64) bool {
+		ret.data[index] ^= mask
+		return true
+	})
+	return ret
+}
+
+// CountRange returns the number of bits set in the range [start, end).
+// start and end are clamped to [0, Len()].
+func (bf *BitField) CountRange(start, end int) int {
+	count := 0
+	bf.forRange(start, end, func(index int, mask BitField64) bool {
+		count += (bf.data[index] & mask).OnesCount()
+		return true
+	})
+	return count
+}
+
+// AllInRange tells if all bits in the range [start, end) are set.
+// start and end are clamped to [0, Len()]. Returns true for an empty range.
+func (bf *BitField) AllInRange(start, end int) bool {
+	all := true
+	bf.forRange(start, end, func(index int, mask BitField64) bool {
+		all = bf.data[index]&mask == mask
+		return all
+	})
+	return all
+}
+
+// AnyInRange tells if any bit in the range [start, end) is set.
+// start and end are clamped to [0, Len()].
+func (bf *BitField) AnyInRange(start, end int) bool {
+	found := false
+	bf.forRange(start, end, func(index int, mask BitField64) bool {
+		found = bf.data[index]&mask != 0
+		return !found
+	})
+	return found
+}
 
 // And does a binary AND with bfOther. Panics if lengths differ. Mutable.
 func (bf *BitField) And(bfOther *BitField) *BitField {
+	return must(bf.TryAnd(bfOther))
+}
+
+// TryAnd is the same as And but returns ErrLengthMismatch instead of
+// panicking if lengths differ. Mutable.
+func (bf *BitField) TryAnd(bfOther *BitField) (*BitField, error) {
 	if bf.len != bfOther.len {
-		panic(errLenOther)
+		return nil, ErrLengthMismatch
 	}
-	ret := bf.mClone()
-	for i := range ret.data {
-		ret.data[i] = ret.data[i].And(bfOther.data[i])
-	}
-	return ret
+	return bf.mDest().AndOf(bf, bfOther), nil
 }
 
 // Or does a binary OR with bfOther. Panics if lengths differ. Mutable.
 func (bf *BitField) Or(bfOther *BitField) *BitField {
+	return must(bf.TryOr(bfOther))
+}
+
+// TryOr is the same as Or but returns ErrLengthMismatch instead of
+// panicking if lengths differ. Mutable.
+func (bf *BitField) TryOr(bfOther *BitField) (*BitField, error) {
 	if bf.len != bfOther.len {
-		panic(errLenOther)
-	}
-	ret := bf.mClone()
-	for i := range ret.data {
-		ret.data[i] = ret.data[i].Or(bfOther.data[i])
+		return nil, ErrLengthMismatch
 	}
-	return ret
+	return bf.mDest().OrOf(bf, bfOther), nil
 }
 
 // Not does a binary NOT (inverts all bits). Mutable.
 func (bf *BitField) Not() *BitField {
-	ret := bf.mClone()
-	for i := range bf.data {
-		ret.data[i] = ret.data[i].Not()
-	}
-	return ret.clearEnd()
+	return bf.mDest().NotOf(bf)
 }
 
 // Xor does a binary XOR with bfOther. Panics if lengths differ. Mutable.
 func (bf *BitField) Xor(bfOther *BitField) *BitField {
+	return must(bf.TryXor(bfOther))
+}
+
+// TryXor is the same as Xor but returns ErrLengthMismatch instead of
+// panicking if lengths differ. Mutable.
+func (bf *BitField) TryXor(bfOther *BitField) (*BitField, error) {
 	if bf.len != bfOther.len {
-		panic(errLenOther)
+		return nil, ErrLengthMismatch
 	}
-	ret := bf.mClone()
+	return bf.mDest().XorOf(bf, bfOther), nil
+}
+
+// AndNot clears the bits that are set in bfOther (set difference).
+// Panics if lengths differ. Mutable.
+func (bf *BitField) AndNot(bfOther *BitField) *BitField {
+	return must(bf.TryAndNot(bfOther))
+}
+
+// TryAndNot is the same as AndNot but returns ErrLengthMismatch instead of
+// panicking if lengths differ. Mutable.
+func (bf *BitField) TryAndNot(bfOther *BitField) (*BitField, error) {
+	if bf.len != bfOther.len {
+		return nil, ErrLengthMismatch
+	}
+	return bf.mDest().AndNotOf(bf, bfOther), nil
+}
+
+// The predicates and counters below accept bitfields of different length:
+// bits beyond the Len() of the shorter one are taken as zero.
+
+// IsSubsetOf tells if all bits set in bf are set in bfOther as well.
+func (bf *BitField) IsSubsetOf(bfOther *BitField) bool {
 	for i := range bf.data {
-		ret.data[i] = ret.data[i].Xor(bfOther.data[i])
+		w := bf.data[i]
+		if i < len(bfOther.data) {
+			w = w.AndNot(bfOther.data[i])
+		}
+		if w != 0 {
+			return false
+		}
 	}
-	return ret.clearEnd()
+	return true
+}
+
+// IsSupersetOf tells if all bits set in bfOther are set in bf as well.
+func (bf *BitField) IsSupersetOf(bfOther *BitField) bool {
+	return bfOther.IsSubsetOf(bf)
+}
+
+// Intersects tells if bf and bfOther have at least one set bit in common.
+func (bf *BitField) Intersects(bfOther *BitField) bool {
+	n := min(len(bf.data), len(bfOther.data))
+	for i := 0; i < n; i++ {
+		if bf.data[i].And(bfOther.data[i]) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDisjoint tells if bf and bfOther have no set bit in common.
+func (bf *BitField) IsDisjoint(bfOther *BitField) bool {
+	return !bf.Intersects(bfOther)
+}
+
+// IntersectionCount returns the number of bits set in both bf and bfOther:
+// the OnesCount() of And() without creating the intermediate bitfield.
+func (bf *BitField) IntersectionCount(bfOther *BitField) int {
+	count := 0
+	n := min(len(bf.data), len(bfOther.data))
+	for i := 0; i < n; i++ {
+		count += bf.data[i].And(bfOther.data[i]).OnesCount()
+	}
+	return count
+}
+
+// UnionCount returns the number of bits set in bf or bfOther:
+// the OnesCount() of Or() without creating the intermediate bitfield.
+func (bf *BitField) UnionCount(bfOther *BitField) int {
+	a, b := bf.data, bfOther.data
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	count := 0
+	for i := range a {
+		w := a[i]
+		if i < len(b) {
+			w = w.Or(b[i])
+		}
+		count += w.OnesCount()
+	}
+	return count
+}
+
+// XorCount returns the number of bits set in exactly one of bf and bfOther:
+// the OnesCount() of Xor() without creating the intermediate bitfield.
+func (bf *BitField) XorCount(bfOther *BitField) int {
+	a, b := bf.data, bfOther.data
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	count := 0
+	for i := range a {
+		w := a[i]
+		if i < len(b) {
+			w = w.Xor(b[i])
+		}
+		count += w.OnesCount()
+	}
+	return count
+}
+
+// AndNotCount returns the number of bits set in bf but not in bfOther:
+// the OnesCount() of AndNot() without creating the intermediate bitfield.
+func (bf *BitField) AndNotCount(bfOther *BitField) int {
+	count := 0
+	for i := range bf.data {
+		w := bf.data[i]
+		if i < len(bfOther.data) {
+			w = w.AndNot(bfOther.data[i])
+		}
+		count += w.OnesCount()
+	}
+	return count
 }
 
 // Equal tells if two bitfields are equal or not
@@ -272,63 +641,44 @@ func (bf *BitField) Equal(bfOther *BitField) bool {
 // Bits exiting at one end are discarded;
 // bits entering at the other end are zeroed. Mutable.
 func (bf *BitField) Shift(count int) *BitField {
-	ret := bf.mClone()
-	if count <= -bf.Len() || count >= bf.Len() {
-		return ret.ClearAll()
-	}
-
-	const n = 64
-	switch {
-	case count == 0:
-		return ret
-	case count > 0:
-		ix, delta := count/n, count%n
-		for i := len(ret.data) - 1; i >= 0; i-- {
-			tmp := New64()
-			if i-ix >= 0 {
-				tmp = ret.data[i-ix]
-			}
-			a, b := tmp.Shift2(delta)
-			ret.data[i] = a
-			if i+1 < len(bf.data) {
-				ret.data[i+1] = ret.data[i+1].Or(b)
-			}
-		}
-		ret.clearEnd()
-
-	case count < 0:
-		ix, delta := -count/n, -count%n
-		for i := 0; i < len(ret.data); i++ {
-			tmp := New64()
-			if i+ix < len(ret.data) {
-				tmp = ret.data[i+ix]
-			}
-			a, b := tmp.Shift2(-delta)
-			ret.data[i] = a
-			if i > 0 {
-				ret.data[i-1] = ret.data[i-1].Or(b)
-			}
-		}
-	}
-	return ret
+	return bf.mDest().ShiftOf(bf, count)
 }
 
 // Mid returns counts bits from position pos as a new BitField
-// Panics if count<0
+// Bits beyond Len() are read as zero.
+// Panics if count<0, or with ErrOutOfRange if the policy is Strict and
+// [pos,pos+count) is not within [0,Len())
 func (bf *BitField) Mid(pos, count int) *BitField {
+	return must(bf.TryMid(pos, count))
+}
+
+// TryMid is the same as Mid but returns ErrNegativeLength or ErrOutOfRange
+// instead of panicking
+func (bf *BitField) TryMid(pos, count int) (*BitField, error) {
 	switch {
 	case count < 0:
-		panic("count cannot be negative")
+		return nil, ErrNegativeLength
 
 	case count == 0:
-		return New(0)
+		return bf.resize(0), nil
 
 	default:
+		if bf.policy == Strict && (pos < 0 || pos+count > bf.len) {
+			return nil, ErrOutOfRange
+		}
 		if count > bf.Len() {
 			count = bf.Len()
 		}
-		pos = bf.posNormalize(pos)
-		return bf.Shift(-pos).resize(count)
+		pos, err := bf.normalize(pos)
+		if err != nil {
+			return nil, err
+		}
+		ret := bf.newLike(count)
+		if pos < 0 || pos >= bf.len {
+			return ret, nil
+		}
+		copyBits(ret, 0, bf, pos, min(count, bf.len-pos))
+		return ret, nil
 	}
 }
 
@@ -338,49 +688,63 @@ func (bf *BitField) Left(count int) *BitField {
 	return bf.Mid(0, count)
 }
 
+// TryLeft is the same as Left but returns ErrNegativeLength instead of
+// panicking if count<0
+func (bf *BitField) TryLeft(count int) (*BitField, error) {
+	return bf.TryMid(0, count)
+}
+
 // Right returns count bits in the range of [63-count,63] as a new BitField
 // Panics if count<0
 func (bf *BitField) Right(count int) *BitField {
+	return must(bf.TryRight(count))
+}
+
+// TryRight is the same as Right but returns ErrNegativeLength instead of
+// panicking if count<0
+func (bf *BitField) TryRight(count int) (*BitField, error) {
 	if count > bf.len {
 		count = bf.len
 	}
-	return bf.Mid(bf.Len()-count, count)
+	return bf.TryMid(bf.Len()-count, count)
 }
 
-// Append appends 'other' BitField to the end
-// A newly created bitfield will be returned
+// Append appends 'other' BitField to the end. Mutable.
 func (bf *BitField) Append(other *BitField) *BitField {
-	if other.Len() == 0 {
-		return bf.Clone()
+	offset, count := bf.len, other.len
+	ret := bf
+	if bf.mutable {
+		bf.grow(offset + count)
+	} else {
+		ret = bf.newLike(offset + count)
+		copy(ret.data, bf.data)
 	}
-	len := bf.Len()
-	newLen := len + other.Len()
-	return other.
-		resize(newLen).
-		Shift(len).
-		Or(bf.resize(newLen))
+	copyBits(ret, offset, other, 0, count)
+	return ret
 }
 
 // Rotate rotates by amount bits and returns it
 // If amount>0 it rotates towards higher bit positions,
 // otherwise it rotates towards lower bit positions. Mutable.
+// Panics with ErrOutOfRange if the policy is Strict and |amount|>=Len()
 func (bf *BitField) Rotate(amount int) *BitField {
-	ret := bf.mClone()
-	if bf.len == 0 {
-		return ret
+	amount, err := bf.rotateAmount(amount)
+	if err != nil {
+		panic(err)
 	}
-	for amount < 0 {
-		amount += bf.len
+	if amount == 0 {
+		return bf.mClone()
 	}
-	amount %= bf.len
-
-	if amount%bf.len == 0 {
-		return ret
+	if bf.mutable {
+		bf.own()
+		bf.reverseBits(0, bf.len)
+		bf.reverseBits(0, amount)
+		bf.reverseBits(amount, bf.len)
+		return bf
 	}
-
-	lh := bf.Left(bf.Len() - amount)
-	rh := bf.Right(amount)
-	rh.Append(lh).Copy(ret)
+	ret := bf.newLike(bf.len)
+	copyBits(ret, 0, bf, bf.len-amount, amount)
+	copyBits(ret, amount, bf, 0, bf.len-amount)
 	return ret
 }
 
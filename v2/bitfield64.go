@@ -96,6 +96,11 @@ func (bf64 BitField64) Or(bfo BitField64) BitField64 {
 	return bf64 | bfo
 }
 
+// AndNot returns the bits of bf64 that are not set in bfo: bf64 AND NOT bfo
+func (bf64 BitField64) AndNot(bfo BitField64) BitField64 {
+	return bf64 &^ bfo
+}
+
 // Not returns the bitfield with each bit inverted: 0 becomes 1, 1 becomes 0
 func (bf64 BitField64) Not() BitField64 {
 	return ^bf64
@@ -111,18 +116,26 @@ func (bf64 BitField64) OnesCount() int {
 	return bits.OnesCount64(uint64(bf64))
 }
 
-// Mid returns count bits from position pos
+// Mid returns count bits from position pos. Bits past position 63 are read as
+// zero. Panics if count<0
 func (bf64 BitField64) Mid(pos, count int) BitField64 {
+	ret, err := bf64.TryMid(pos, count)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// TryMid is the same as Mid but returns ErrNegativeLength instead of
+// panicking if count<0
+func (bf64 BitField64) TryMid(pos, count int) (BitField64, error) {
 	if count < 0 {
-		panic("count is negative")
+		return 0, ErrNegativeLength
 	}
 	const n = 64
 	count = count % n
 	pos = posNormalize(pos)
-	end := pos + count
-	a := bf64 << (n - end)
-	a = a >> (n - count)
-	return a
+	return (bf64 >> uint(pos)) & rangeMask(0, count), nil
 }
 
 // Left returns leftmost count bits: [0, count-1]
@@ -130,12 +143,25 @@ func (bf64 BitField64) Left(count int) BitField64 {
 	return bf64.Mid(0, count)
 }
 
+// TryLeft is the same as Left but returns ErrNegativeLength instead of
+// panicking if count<0
+func (bf64 BitField64) TryLeft(count int) (BitField64, error) {
+	return bf64.TryMid(0, count)
+}
+
 // Right returns rightmost count bits [63-count, 63]
 func (bf64 BitField64) Right(count int) BitField64 {
 	const n = 64
 	return bf64.Mid(n-count, count)
 }
 
+// TryRight is the same as Right but returns ErrNegativeLength instead of
+// panicking if count<0
+func (bf64 BitField64) TryRight(count int) (BitField64, error) {
+	const n = 64
+	return bf64.TryMid(n-count, count)
+}
+
 // Rotate rotates by count bits: Bits exiting at one end entering at the other
 // end. If count is positive it rotates towards higher positions; If negative it
 // rotates towards lower positions.
@@ -211,3 +237,123 @@ func (bf64 BitField64) String() string {
 func (bf64 BitField64) StringPretty() string {
 	return bf64.toString(true)
 }
+
+// NextSet returns the position of the first set bit at or after from.
+// The search does not wrap around: a from below 0 starts at 0 and false is
+// returned if no set bit is found up to position 63.
+func (bf64 BitField64) NextSet(from int) (int, bool) {
+	const n = 64
+	if from >= n {
+		return -1, false
+	}
+	if from < 0 {
+		from = 0
+	}
+	w := uint64(bf64) >> uint(from)
+	if w == 0 {
+		return -1, false
+	}
+	return from + bits.TrailingZeros64(w), true
+}
+
+// NextClear returns the position of the first cleared bit at or after from.
+// See NextSet for the handling of from.
+func (bf64 BitField64) NextClear(from int) (int, bool) {
+	return bf64.Not().NextSet(from)
+}
+
+// PrevSet returns the position of the last set bit at or before from.
+// The search does not wrap around: a from above 63 starts at 63 and false is
+// returned if no set bit is found down to position 0.
+func (bf64 BitField64) PrevSet(from int) (int, bool) {
+	const n = 64
+	if from < 0 {
+		return -1, false
+	}
+	if from >= n {
+		from = n - 1
+	}
+	w := uint64(bf64) << uint(n-1-from)
+	if w == 0 {
+		return -1, false
+	}
+	return from - bits.LeadingZeros64(w), true
+}
+
+// PrevClear returns the position of the last cleared bit at or before from.
+// See PrevSet for the handling of from.
+func (bf64 BitField64) PrevClear(from int) (int, bool) {
+	return bf64.Not().PrevSet(from)
+}
+
+// LeadingZeros returns the number of cleared bits before the highest set bit,
+// counting down from position 63. Returns 64 if no bit is set.
+func (bf64 BitField64) LeadingZeros() int {
+	return bits.LeadingZeros64(uint64(bf64))
+}
+
+// TrailingZeros returns the number of cleared bits before the lowest set bit,
+// counting up from position 0. Returns 64 if no bit is set.
+func (bf64 BitField64) TrailingZeros() int {
+	return bits.TrailingZeros64(uint64(bf64))
+}
+
+// BitLen returns the position of the highest set bit plus one,
+// or 0 if no bit is set.
+func (bf64 BitField64) BitLen() int {
+	return bits.Len64(uint64(bf64))
+}
+
+// rangeMask returns a mask with the bits in [start, end) set.
+// start and end are clamped to [0, 64].
+func rangeMask(start, end int) BitField64 {
+	const n = 64
+	if start < 0 {
+		start = 0
+	}
+	if end > n {
+		end = n
+	}
+	if start >= end {
+		return 0
+	}
+	return BitField64(math.MaxUint64<<uint(start)) &
+		BitField64(math.MaxUint64>>uint(n-end))
+}
+
+// SetRange sets the bits in the range [start, end).
+// start and end are clamped to [0, 64].
+func (bf64 BitField64) SetRange(start, end int) BitField64 {
+	return bf64 | rangeMask(start, end)
+}
+
+// ClearRange clears the bits in the range [start, end).
+// start and end are clamped to [0, 64].
+func (bf64 BitField64) ClearRange(start, end int) BitField64 {
+	return bf64 &^ rangeMask(start, end)
+}
+
+// FlipRange inverts the bits in the range [start, end).
+// start and end are clamped to [0, 64].
+func (bf64 BitField64) FlipRange(start, end int) BitField64 {
+	return bf64 ^ rangeMask(start, end)
+}
+
+// CountRange returns the number of bits set in the range [start, end).
+// start and end are clamped to [0, 64].
+func (bf64 BitField64) CountRange(start, end int) int {
+	return (bf64 & rangeMask(start, end)).OnesCount()
+}
+
+// AllInRange tells if all bits in the range [start, end) are set.
+// start and end are clamped to [0, 64]. Returns true for an empty range.
+func (bf64 BitField64) AllInRange(start, end int) bool {
+	mask := rangeMask(start, end)
+	return bf64&mask == mask
+}
+
+// AnyInRange tells if any bit in the range [start, end) is set.
+// start and end are clamped to [0, 64].
+func (bf64 BitField64) AnyInRange(start, end int) bool {
+	return bf64&rangeMask(start, end) != 0
+}
@@ -51,6 +51,9 @@ func Test64_1(t *testing.T) {
 	if bf2.And(bf1).OnesCount() != 2 {
 		t.Error("should be 2")
 	}
+	if bf2.AndNot(bf1) != New64().Set(3) {
+		t.Error("should be 0001")
+	}
 
 	if New64().SetAll().ClearAll().OnesCount() != 0 {
 		t.Error("should be zero")
@@ -188,6 +191,76 @@ func TestShift2(t *testing.T) {
 
 }
 
+func TestNextPrev64(t *testing.T) {
+	a := New64().SetMul(2, 40, 63)
+	tests := []struct {
+		from   int
+		next   int
+		nextOk bool
+		prev   int
+		prevOk bool
+	}{
+		{-1, 2, true, -1, false},
+		{2, 2, true, 2, true},
+		{3, 40, true, 2, true},
+		{41, 63, true, 40, true},
+		{64, -1, false, 63, true},
+	}
+	for _, tt := range tests {
+		pos, ok := a.NextSet(tt.from)
+		if pos != tt.next || ok != tt.nextOk {
+			t.Errorf("NextSet(%d) = %d,%v", tt.from, pos, ok)
+		}
+		pos, ok = a.PrevSet(tt.from)
+		if pos != tt.prev || ok != tt.prevOk {
+			t.Errorf("PrevSet(%d) = %d,%v", tt.from, pos, ok)
+		}
+	}
+	if pos, _ := a.NextClear(2); pos != 3 {
+		t.Error("should be 3")
+	}
+	if pos, _ := a.PrevClear(63); pos != 62 {
+		t.Error("should be 62")
+	}
+	if _, ok := New64().SetAll().NextClear(0); ok {
+		t.Error("should be false")
+	}
+	if _, ok := New64().PrevSet(10); ok {
+		t.Error("should be false")
+	}
+	if a.TrailingZeros() != 2 || a.LeadingZeros() != 0 || a.BitLen() != 64 {
+		t.Error("wrong zero counts")
+	}
+	if New64().Set(5).LeadingZeros() != 58 || New64().BitLen() != 0 {
+		t.Error("wrong zero counts")
+	}
+}
+
+func TestRange64(t *testing.T) {
+	a := New64().SetRange(3, 10)
+	if a.StringPretty() != "0001111111" {
+		t.Error("should be 0001111111")
+	}
+	if a.CountRange(0, 5) != 2 || a.CountRange(-3, 100) != 7 {
+		t.Error("wrong count")
+	}
+	if !a.AllInRange(3, 10) || a.AllInRange(2, 10) || !a.AllInRange(4, 4) {
+		t.Error("wrong AllInRange")
+	}
+	if !a.AnyInRange(9, 64) || a.AnyInRange(10, 64) || a.AnyInRange(8, 3) {
+		t.Error("wrong AnyInRange")
+	}
+	if a.ClearRange(0, 5).OnesCount() != 5 {
+		t.Error("should be 5")
+	}
+	if a.FlipRange(0, 64).OnesCount() != 57 {
+		t.Error("should be 57")
+	}
+	if New64().SetRange(-1, 65) != New64().SetAll() {
+		t.Error("should be all set")
+	}
+}
+
 func ExampleBitField64_SetMul() {
 	a := New64().SetMul(2, 4)
 	fmt.Println(a.StringPretty())
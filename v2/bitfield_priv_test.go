@@ -44,12 +44,82 @@ func TestPrivate2(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		ix, p := New(tt.size).posToOffset(tt.offset)
-		if ix == tt.expectedIx && p == tt.expectedPos {
+		ix, p, ok := New(tt.size).locate(tt.offset, false)
+		if ok && ix == tt.expectedIx && p == tt.expectedPos {
 			continue
 		}
-		t.Errorf("New(%d).posToOffset(%d) should map to [%d,%d]. Got: [%d,%d]",
+		t.Errorf("New(%d).locate(%d) should map to [%d,%d]. Got: [%d,%d]",
 			tt.size, tt.offset, tt.expectedIx, tt.expectedPos, ix, p)
 	}
+	if _, _, ok := New(0).locate(0, true); ok {
+		t.Error("empty bitfield should have no bits to locate")
+	}
 }
 
+func TestCOWChunks(t *testing.T) {
+	a := New(3 * chunkWords * 64)
+	f := a.Freeze()
+	assert(t, &f.chunks[0][0] == &a.data[0], true)
+	c := f.Clone().Set(chunkWords*64 + 1)
+	assert(t, c.owned.OnesCount(), 1)
+	for k := range c.chunks {
+		assert(t, &f.chunks[k][0] == &c.chunks[k][0], k != 1)
+	}
+	g := c.Freeze()
+	for k := range c.chunks {
+		assert(t, &g.chunks[k][0] == &c.chunks[k][0], true)
+	}
+	assert(t, c.owned.OnesCount(), 0)
+
+	// writing to a frozen bitfield copies its words, the snapshot keeps them
+	a.Mut().Set(1)
+	assert(t, &f.chunks[0][0] == &a.data[0], false)
+	assert(t, f.Get(1), false)
+	b := a.Clone()
+	assert(t, &b.data[0] == &a.data[0], false)
+}
+
+func TestSparsePages(t *testing.T) {
+	var s Sparse
+	for i := uint64(10); i > 0; i-- {
+		s.Set(i << 40)
+	}
+	s.Set(1<<40 + 1)
+	assert(t, s.Pages(), 10)
+	assert(t, s.pages[1<<40/sparsePageBits].count, 2)
+	assert(t, s.pages[1<<40/sparsePageBits].bits.Len(), sparsePageBits)
+	for k := 1; k < len(s.keys); k++ {
+		assert(t, s.keys[k-1] < s.keys[k], true)
+	}
+	s.Clear(1 << 40).Clear(1 << 40).Clear(1<<40 + 1)
+	assert(t, s.Pages(), 9)
+	assert(t, len(s.keys), 9)
+	assert(t, s.keys[0], uint64(2<<40/sparsePageBits))
+	assert(t, s.OnesCount(), 9)
+}
+
+func TestAtomicSnapshotBusy(t *testing.T) {
+	// bit far is only set while bit 0 is. Between the loads of word 0 and
+	// the word of far the writer sets both bits, after it clears them again:
+	// every copy sees bit far without bit 0, and two copies in a row agree.
+	for _, far := range []int{64, atomicStripeWords * 64} {
+		a := NewAtomic(2 * far)
+		rounds := 0
+		snapshotHook = func(index int) {
+			switch {
+			case rounds == 3:
+			case index == 0:
+				a.Set(0)
+				a.Set(far)
+			case index == far/64:
+				a.Clear(far)
+				a.Clear(0)
+				rounds++
+			}
+		}
+		s := a.Snapshot()
+		snapshotHook = nil
+		assert(t, rounds, 3)
+		assert(t, s.OnesCount(), 0)
+	}
+}
@@ -2,8 +2,9 @@ package bitfield_test
 
 import (
 	"fmt"
-	"testing"
 	. "github.com/bukshee/bitfield/v2"
+	"math/rand"
+	"testing"
 )
 
 func assert(t *testing.T, a, b interface{}) {
@@ -248,6 +249,66 @@ func TestRotate(t *testing.T) {
 	assert(t, a.String(), "001")
 }
 
+// randomBitField returns a bitfield of length size with random bits
+func randomBitField(r *rand.Rand, size int) *BitField {
+	a := New(size).Mut()
+	for i := 0; i < size; i++ {
+		if r.Intn(2) == 1 {
+			a.Set(i)
+		}
+	}
+	return New(size).Or(a)
+}
+
+func TestWordLevel(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, size := range []int{1, 5, 63, 64, 65, 127, 128, 129, 300} {
+		a := randomBitField(r, size)
+		for n := -size - 1; n <= size+1; n += 1 + size/17 {
+			shifted, rotated := New(size), New(size)
+			for i := 0; i < size; i++ {
+				if a.Get(i) {
+					if i+n >= 0 && i+n < size {
+						shifted.Mut().Set(i + n)
+					}
+					rotated.Mut().Set(i + n)
+				}
+			}
+			assert(t, a.Shift(n).Equal(shifted), true)
+			assert(t, a.Rotate(n).Equal(rotated), true)
+			assert(t, a.Clone().Mut().Shift(n).Equal(shifted), true)
+			assert(t, a.Clone().Mut().Rotate(n).Equal(rotated), true)
+		}
+		for pos := 0; pos < size; pos += 1 + size/13 {
+			for count := 0; count <= size; count += 1 + size/7 {
+				m := a.Mid(pos, count)
+				assert(t, m.Len(), count)
+				for i := 0; i < count; i++ {
+					if m.Get(i) != (pos+i < size && a.Get(pos+i)) {
+						t.Fatalf("New(%d).Mid(%d,%d) differs at %d", size, pos, count, i)
+					}
+				}
+			}
+		}
+		b := randomBitField(r, size/2+3)
+		c := a.Append(b)
+		assert(t, c.Len(), size+b.Len())
+		assert(t, c.Left(size).Equal(a), true)
+		assert(t, c.Right(b.Len()).Equal(b), true)
+		d := a.Clone().Mut()
+		d.Append(d)
+		assert(t, d.Equal(a.Append(a)), true)
+	}
+}
+
+func TestWordLevelAllocs(t *testing.T) {
+	a := New(1000).Set(1, 500, 999).Mut()
+	allocs := testing.AllocsPerRun(10, func() {
+		a.Rotate(333).Rotate(-7).Shift(3).Shift(-3)
+	})
+	assert(t, allocs, 0.0)
+}
+
 func TestMut(t *testing.T) {
 	a := New(65).Mut()
 	a.SetAll().Clear(0, -1).Flip(3, 4)
@@ -265,6 +326,130 @@ func TestMut(t *testing.T) {
 	assert(t, a.Get(-1), true)
 }
 
+func TestNextPrev(t *testing.T) {
+	a := New(200).Set(3, 64, 130, 199)
+	tests := []struct {
+		from   int
+		next   int
+		nextOk bool
+		prev   int
+		prevOk bool
+	}{
+		{-5, 3, true, -1, false},
+		{0, 3, true, -1, false},
+		{3, 3, true, 3, true},
+		{4, 64, true, 3, true},
+		{65, 130, true, 64, true},
+		{199, 199, true, 199, true},
+		{250, -1, false, 199, true},
+	}
+	for _, tt := range tests {
+		pos, ok := a.NextSet(tt.from)
+		if pos != tt.next || ok != tt.nextOk {
+			t.Errorf("NextSet(%d) = %d,%v", tt.from, pos, ok)
+		}
+		pos, ok = a.PrevSet(tt.from)
+		if pos != tt.prev || ok != tt.prevOk {
+			t.Errorf("PrevSet(%d) = %d,%v", tt.from, pos, ok)
+		}
+	}
+
+	b := a.Clone().Not()
+	pos, ok := b.NextClear(4)
+	assert(t, pos, 64)
+	pos, ok = b.PrevClear(129)
+	assert(t, pos, 64)
+	pos, ok = b.NextClear(200)
+	assert(t, ok, false)
+
+	pos, ok = New(128).SetAll().NextClear(0)
+	assert(t, ok, false)
+	pos, ok = New(128).SetAll().PrevClear(127)
+	assert(t, ok, false)
+	pos, ok = New(70).NextClear(69)
+	assert(t, pos, 69)
+	pos, ok = New(0).PrevSet(0)
+	assert(t, ok, false)
+	pos, ok = New(0).PrevClear(0)
+	assert(t, ok, false)
+
+	assert(t, a.TrailingZeros(), 3)
+	assert(t, a.BitLen(), 200)
+	assert(t, a.LeadingZeros(), 0)
+	assert(t, New(100).Set(70).LeadingZeros(), 29)
+	assert(t, New(100).TrailingZeros(), 100)
+	assert(t, New(100).LeadingZeros(), 100)
+	assert(t, New(100).BitLen(), 0)
+}
+
+func TestRange(t *testing.T) {
+	a := New(200).SetRange(10, 150)
+	assert(t, a.OnesCount(), 140)
+	assert(t, a.Get(9), false)
+	assert(t, a.Get(10), true)
+	assert(t, a.Get(149), true)
+	assert(t, a.Get(150), false)
+	assert(t, a.CountRange(0, 64), 54)
+	assert(t, a.CountRange(-10, 1000), 140)
+	assert(t, a.CountRange(20, 10), 0)
+	assert(t, a.AllInRange(10, 150), true)
+	assert(t, a.AllInRange(9, 150), false)
+	assert(t, a.AllInRange(5, 5), true)
+	assert(t, a.AnyInRange(150, 200), false)
+	assert(t, a.AnyInRange(0, 11), true)
+
+	a = a.ClearRange(64, 128)
+	assert(t, a.OnesCount(), 76)
+	a = a.FlipRange(0, 300)
+	assert(t, a.OnesCount(), 124)
+	assert(t, New(200).SetRange(-5, 500).Equal(New(200).SetAll()), true)
+
+	b := New(70)
+	b.SetRange(0, 70)
+	assert(t, b.OnesCount(), 0)
+	b.Mut().SetRange(0, 70)
+	assert(t, b.OnesCount(), 70)
+}
+
+func TestSetAlgebra(t *testing.T) {
+	a := New(130).Set(1, 64, 129)
+	b := New(130).Set(1, 2, 64, 100, 129)
+	c := New(130).Set(2, 100)
+
+	assert(t, b.AndNot(a).Equal(c), true)
+	assert(t, a.Clone().AndNot(a).OnesCount(), 0)
+	if !doesPanic(func() { New(5).AndNot(New(121)) }) {
+		t.Error("should panic")
+	}
+
+	assert(t, a.IsSubsetOf(b), true)
+	assert(t, b.IsSubsetOf(a), false)
+	assert(t, b.IsSupersetOf(a), true)
+	assert(t, a.IsSupersetOf(b), false)
+	assert(t, a.Intersects(b), true)
+	assert(t, a.Intersects(c), false)
+	assert(t, a.IsDisjoint(c), true)
+	assert(t, a.IsDisjoint(b), false)
+
+	assert(t, a.IntersectionCount(b), 3)
+	assert(t, a.UnionCount(c), 5)
+	assert(t, a.XorCount(b), 2)
+	assert(t, b.AndNotCount(a), 2)
+	assert(t, a.AndNotCount(b), 0)
+
+	// different lengths: missing bits are zero
+	d := New(3).Set(1)
+	assert(t, d.IsSubsetOf(a), true)
+	assert(t, a.IsSubsetOf(d), false)
+	assert(t, New(200).IsSubsetOf(d), true)
+	assert(t, d.Intersects(a), true)
+	assert(t, d.IntersectionCount(a), 1)
+	assert(t, d.UnionCount(a), 3)
+	assert(t, a.UnionCount(d), 3)
+	assert(t, d.XorCount(a), 2)
+	assert(t, d.AndNotCount(a), 0)
+}
+
 func Benchmark1(b *testing.B) {
 	a := New(365)
 	for n := 0; n < b.N; n++ {
@@ -272,6 +457,20 @@ func Benchmark1(b *testing.B) {
 	}
 }
 
+func BenchmarkRotate(b *testing.B) {
+	a := New(1<<16).Set(0, 1000).Mut()
+	for n := 0; n < b.N; n++ {
+		a.Rotate(12345)
+	}
+}
+
+func BenchmarkMid(b *testing.B) {
+	a := New(1 << 16).SetAll()
+	for n := 0; n < b.N; n++ {
+		a.Mid(1000, 100)
+	}
+}
+
 func ExampleBitField_Shift_e1() {
 	bf := NewBitField(3).Set(0).Shift(1)
 	fmt.Println(bf)
@@ -301,3 +500,13 @@ func ExampleBitField_Mut() {
 	// with Mut(): 1100
 
 }
+
+func ExampleBitField_NextSet() {
+	bf := NewBitField(130).Set(1, 70, 129)
+	for pos, ok := bf.NextSet(0); ok; pos, ok = bf.NextSet(pos + 1) {
+		fmt.Println(pos)
+	}
+	// Output: 1
+	// 70
+	// 129
+}
@@ -0,0 +1,200 @@
+package bitfield
+
+import (
+	"io"
+	"math/bits"
+)
+
+const (
+	// readChunk is the number of bytes BitReader reads from an io.Reader at once
+	readChunk = 4096
+	// maxEmptyReads is the number of reads in a row returning no data and no
+	// error after which BitReader gives up with io.ErrNoProgress, as bufio does
+	maxEmptyReads = 100
+)
+
+// streamValue converts between the low n bits of a value and the bits of
+// the stream in order: LSB0 streams the least significant bit first, MSB0
+// the most significant one. It is its own inverse.
+func streamValue(v uint64, n int, order BitOrder) uint64 {
+	if order == MSB0 {
+		return bits.Reverse64(v) >> uint(64-n)
+	}
+	return v
+}
+
+// BitWriter writes values of arbitrary bit widths into a growing BitField.
+type BitWriter struct {
+	bits  *BitField
+	order BitOrder
+}
+
+// NewBitWriter creates a writer streaming the bits of values in order.
+func NewBitWriter(order BitOrder) *BitWriter {
+	return &BitWriter{bits: New(0).Mut(), order: order}
+}
+
+// Len returns the number of bits written.
+func (w *BitWriter) Len() int {
+	return w.bits.len
+}
+
+// WriteBits writes the low n bits of v. Panics with ErrOutOfRange if n is not
+// in [0, 64].
+func (w *BitWriter) WriteBits(v uint64, n int) *BitWriter {
+	if n < 0 || n > 64 {
+		panic(ErrOutOfRange)
+	}
+	pos := w.bits.len
+	w.bits.grow(pos + n)
+	w.bits.setBits(pos, n, streamValue(v, n, w.order))
+	return w
+}
+
+// WriteBool writes a single bit.
+func (w *BitWriter) WriteBool(b bool) *BitWriter {
+	if b {
+		return w.WriteBits(1, 1)
+	}
+	return w.WriteBits(0, 1)
+}
+
+// Align writes zero bits up to the next byte boundary.
+func (w *BitWriter) Align() *BitWriter {
+	return w.WriteBits(0, -w.bits.len&7)
+}
+
+// BitField returns a copy of the bits written.
+func (w *BitWriter) BitField() *BitField {
+	return w.bits.Clone()
+}
+
+// Bytes returns the bits written as bytes, the first bit being bit 0 of the
+// first byte for LSB0 and bit 7 for MSB0. The last byte is padded with
+// zeros.
+func (w *BitWriter) Bytes() []byte {
+	return w.bits.Bytes(Order{Bit: w.order})
+}
+
+// BitReader reads values of arbitrary bit widths from a BitField or an
+// io.Reader. Short input returns io.EOF if no bits are left and
+// io.ErrUnexpectedEOF otherwise.
+type BitReader struct {
+	buf    *BitField // buffered bits, the unread ones start at pos
+	pos    int
+	offset int // bits consumed
+	order  BitOrder
+	r      io.Reader
+	err    error // sticky error of r
+}
+
+// NewBitReader creates a reader over the bits of bf, which must not be
+// modified while reading. The bits of values are streamed in order.
+func NewBitReader(bf *BitField, order BitOrder) *BitReader {
+	return &BitReader{buf: bf, order: order}
+}
+
+// NewBitReaderFrom creates a reader over the bytes of r. Bits are taken from
+// each byte starting at bit 0 for LSB0 and at bit 7 for MSB0.
+func NewBitReaderFrom(r io.Reader, order BitOrder) *BitReader {
+	return &BitReader{buf: New(0), order: order, r: r}
+}
+
+// Offset returns the number of bits consumed.
+func (br *BitReader) Offset() int {
+	return br.offset
+}
+
+// buffered returns the number of unread bits buffered
+func (br *BitReader) buffered() int {
+	return br.buf.len - br.pos
+}
+
+// fill buffers at least n bits if the underlying reader has them.
+func (br *BitReader) fill(n int) {
+	empty := 0
+	for br.buffered() < n && br.r != nil && br.err == nil {
+		chunk := make([]byte, readChunk)
+		k, err := br.r.Read(chunk)
+		if err != nil {
+			br.err = err
+		}
+		if k == 0 {
+			if empty++; empty == maxEmptyReads && br.err == nil {
+				br.err = io.ErrNoProgress
+			}
+			continue
+		}
+		empty = 0
+		rest := br.buffered()
+		buf := New(rest + 8*k)
+		copyBits(buf, 0, br.buf, br.pos, rest)
+		copyBits(buf, rest, must(FromBytes(chunk[:k], 8*k, Order{Bit: br.order})), 0, 8*k)
+		br.buf, br.pos = buf, 0
+	}
+}
+
+// shortErr returns the error of reading past the end
+func (br *BitReader) shortErr() error {
+	if br.err != nil && br.err != io.EOF {
+		return br.err
+	}
+	if br.buffered() == 0 {
+		return io.EOF
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// PeekBits returns the next n bits without consuming them. Panics with
+// ErrOutOfRange if n is not in [0, 64].
+func (br *BitReader) PeekBits(n int) (uint64, error) {
+	if n < 0 || n > 64 {
+		panic(ErrOutOfRange)
+	}
+	if br.fill(n); br.buffered() < n {
+		return 0, br.shortErr()
+	}
+	return streamValue(br.buf.getBits(br.pos, n), n, br.order), nil
+}
+
+// ReadBits reads the next n bits. Panics with ErrOutOfRange if n is not in
+// [0, 64]. Nothing is consumed on error.
+func (br *BitReader) ReadBits(n int) (uint64, error) {
+	v, err := br.PeekBits(n)
+	if err == nil {
+		br.pos += n
+		br.offset += n
+	}
+	return v, err
+}
+
+// ReadBool reads a single bit.
+func (br *BitReader) ReadBool() (bool, error) {
+	v, err := br.ReadBits(1)
+	return v == 1, err
+}
+
+// SkipBits consumes the next n bits. On short input the remaining bits are
+// consumed. Panics with ErrNegativeLength if n<0.
+func (br *BitReader) SkipBits(n int) error {
+	if n < 0 {
+		panic(ErrNegativeLength)
+	}
+	skipped := 0
+	for n > 0 {
+		if br.fill(1); br.buffered() == 0 {
+			if skipped > 0 {
+				return io.ErrUnexpectedEOF
+			}
+			return br.shortErr()
+		}
+		k := min(n, br.buffered())
+		br.pos, br.offset, n, skipped = br.pos+k, br.offset+k, n-k, skipped+k
+	}
+	return nil
+}
+
+// Align skips the bits up to the next byte boundary.
+func (br *BitReader) Align() error {
+	return br.SkipBits(-br.offset & 7)
+}
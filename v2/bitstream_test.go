@@ -0,0 +1,115 @@
+package bitfield_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestBitWriter(t *testing.T) {
+	w := NewBitWriter(LSB0).WriteBits(0b101, 3).WriteBool(true).WriteBits(0, 0)
+	assert(t, w.Len(), 4)
+	assert(t, w.BitField().String(), "1011")
+	assert(t, w.Align().Len(), 8)
+	assert(t, w.Align().Len(), 8)
+	assert(t, fmt.Sprintf("%x", w.WriteBits(0xabc, 12).Bytes()), "0dbc0a")
+
+	w = NewBitWriter(MSB0).WriteBits(0b101, 3).WriteBool(true)
+	assert(t, w.BitField().String(), "1011")
+	assert(t, fmt.Sprintf("%x", w.Align().WriteBits(0xabc, 12).Bytes()), "b0abc0")
+	assert(t, doesPanic(func() { w.WriteBits(0, 65) }), true)
+}
+
+func TestBitReader(t *testing.T) {
+	for _, order := range []BitOrder{LSB0, MSB0} {
+		w := NewBitWriter(order).WriteBits(0b101, 3).WriteBool(true).Align().
+			WriteBits(0xabc, 12).WriteBits(1<<63|1, 64).WriteBits(31, 5)
+		for _, r := range []*BitReader{
+			NewBitReader(w.BitField(), order),
+			NewBitReaderFrom(iotest.OneByteReader(bytes.NewReader(w.Bytes())), order),
+		} {
+			v, err := r.PeekBits(3)
+			assert(t, v, uint64(0b101))
+			assert(t, err, nil)
+			v, _ = r.ReadBits(3)
+			assert(t, v, uint64(0b101))
+			b, _ := r.ReadBool()
+			assert(t, b, true)
+			assert(t, r.Offset(), 4)
+			assert(t, r.Align(), nil)
+			assert(t, r.Offset(), 8)
+			v, _ = r.ReadBits(12)
+			assert(t, v, uint64(0xabc))
+			v, _ = r.ReadBits(64)
+			assert(t, v, uint64(1<<63|1))
+			assert(t, r.SkipBits(2), nil)
+			v, _ = r.ReadBits(0)
+			assert(t, v, uint64(0))
+			// 3 bits of data left in the BitField, 7 in the bytes
+			_, err = r.ReadBits(16)
+			assert(t, errors.Is(err, io.ErrUnexpectedEOF), true)
+			v, _ = r.ReadBits(3)
+			assert(t, v, uint64(7))
+			r.SkipBits(8)
+			_, err = r.ReadBits(1)
+			assert(t, err, io.EOF)
+			assert(t, r.SkipBits(1), io.EOF)
+			assert(t, doesPanic(func() { r.ReadBits(-1) }), true)
+			assert(t, doesPanic(func() { r.SkipBits(-1) }), true)
+		}
+	}
+}
+
+func TestBitStreamRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, order := range []BitOrder{LSB0, MSB0} {
+		type field struct {
+			v uint64
+			n int
+		}
+		var fields []field
+		w := NewBitWriter(order)
+		for i := 0; i < 2000; i++ {
+			n := r.Intn(65)
+			v := r.Uint64() & (1<<uint(n) - 1)
+			if n == 64 {
+				v = r.Uint64()
+			}
+			fields = append(fields, field{v, n})
+			w.WriteBits(v, n)
+		}
+		br := NewBitReaderFrom(bytes.NewReader(w.Bytes()), order)
+		for _, f := range fields {
+			v, err := br.ReadBits(f.n)
+			assert(t, err, nil)
+			assert(t, v, f.v)
+		}
+		assert(t, br.Offset(), w.Len())
+	}
+}
+
+func TestBitReaderError(t *testing.T) {
+	failure := errors.New("failure")
+	r := NewBitReaderFrom(iotest.ErrReader(failure), LSB0)
+	_, err := r.ReadBits(8)
+	assert(t, err, failure)
+}
+
+type emptyReader struct{}
+
+func (emptyReader) Read([]byte) (int, error) {
+	return 0, nil
+}
+
+func TestBitReaderNoProgress(t *testing.T) {
+	r := NewBitReaderFrom(emptyReader{}, LSB0)
+	_, err := r.ReadBits(8)
+	assert(t, err, io.ErrNoProgress)
+	assert(t, r.SkipBits(1), io.ErrNoProgress)
+}
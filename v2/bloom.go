@@ -0,0 +1,195 @@
+package bitfield
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+)
+
+// BloomHash returns two independent 64-bit hashes of data. The k indexes
+// of an item are derived from them by double hashing.
+type BloomHash func(data []byte) (uint64, uint64)
+
+// FNVHash is the default BloomHash: the two halves of the FNV-128a hash,
+// each mixed further as the halves are correlated for short inputs.
+func FNVHash(data []byte) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write(data)
+	var sum [16]byte
+	h.Sum(sum[:0])
+	return mix64(binary.BigEndian.Uint64(sum[:8])), mix64(binary.BigEndian.Uint64(sum[8:]))
+}
+
+// mix64 is the finalizer of SplitMix64
+func mix64(x uint64) uint64 {
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+// Binary format of Bloom: "BL", version 1, uvarint k followed by the
+// encoding of the bitfield.
+const (
+	bloomMagic   = "BL"
+	bloomVersion = 1
+	bloomMaxK    = 1 << 10
+)
+
+// Bloom is a Bloom filter backed by a BitField: a set membership test that
+// may report false positives but no false negatives.
+type Bloom struct {
+	bits *BitField
+	k    int
+	hash BloomHash
+}
+
+// NewBloom creates a filter sized for n items at a false positive rate of
+// p. Panics with ErrNegativeLength if n is negative and with ErrOutOfRange
+// if p is not in (0, 1).
+func NewBloom(n int, p float64) *Bloom {
+	if n < 0 {
+		panic(ErrNegativeLength)
+	}
+	if !(p > 0 && p < 1) {
+		panic(ErrOutOfRange)
+	}
+	n = max(n, 1)
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(n) * math.Ln2)
+	return NewBloomSize(int(m), max(int(k), 1))
+}
+
+// NewBloomSize creates a filter of m bits and k hash functions. Panics with
+// ErrOutOfRange if either is less than 1.
+func NewBloomSize(m, k int) *Bloom {
+	if m < 1 || k < 1 {
+		panic(ErrOutOfRange)
+	}
+	return &Bloom{bits: New(m).Mut(), k: k, hash: FNVHash}
+}
+
+// WithHash sets the hash function, FNVHash by default. Filters can only be
+// combined and decoded with the same hash function they were built with.
+func (b *Bloom) WithHash(h BloomHash) *Bloom {
+	b.hash = h
+	return b
+}
+
+// Len returns the number of bits of the filter.
+func (b *Bloom) Len() int {
+	return b.bits.len
+}
+
+// K returns the number of hash functions.
+func (b *Bloom) K() int {
+	return b.k
+}
+
+// each calls f with the k positions of data, stops if f returns false.
+// Returns false if stopped.
+func (b *Bloom) each(data []byte, f func(pos int) bool) bool {
+	h1, h2 := b.hash(data)
+	m := uint64(b.bits.len)
+	for i := 0; i < b.k; i++ {
+		if !f(int((h1 + uint64(i)*h2) % m)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Add adds data to the filter.
+func (b *Bloom) Add(data []byte) *Bloom {
+	b.each(data, func(pos int) bool {
+		b.bits.Set(pos)
+		return true
+	})
+	return b
+}
+
+// Test tells if data may be in the filter. false means it is definitely not.
+func (b *Bloom) Test(data []byte) bool {
+	return b.each(data, b.bits.Get)
+}
+
+// TestAndAdd adds data to the filter and tells if it may have been in it
+// before.
+func (b *Bloom) TestAndAdd(data []byte) bool {
+	present := true
+	b.each(data, func(pos int) bool {
+		if !b.bits.Get(pos) {
+			present = false
+			b.bits.Set(pos)
+		}
+		return true
+	})
+	return present
+}
+
+// EstimatedCount estimates the number of items added from the number of
+// bits set. Returns +Inf if all bits are set.
+func (b *Bloom) EstimatedCount() float64 {
+	m, x := float64(b.bits.len), float64(b.bits.OnesCount())
+	return -m / float64(b.k) * math.Log(1-x/m)
+}
+
+// compatible panics with ErrLengthMismatch unless the filters have the
+// same size and number of hash functions.
+func (b *Bloom) compatible(other *Bloom) {
+	if b.bits.len != other.bits.len || b.k != other.k {
+		panic(ErrLengthMismatch)
+	}
+}
+
+// Union returns a new filter of the items of b or other. Panics with
+// ErrLengthMismatch if the filters differ in size or K().
+func (b *Bloom) Union(other *Bloom) *Bloom {
+	b.compatible(other)
+	return &Bloom{bits: New(b.bits.len).Mut().OrOf(b.bits, other.bits), k: b.k, hash: b.hash}
+}
+
+// Intersect returns a new filter approximating the items in both b and
+// other. Panics with ErrLengthMismatch if the filters differ in size or K().
+func (b *Bloom) Intersect(other *Bloom) *Bloom {
+	b.compatible(other)
+	return &Bloom{bits: New(b.bits.len).Mut().AndOf(b.bits, other.bits), k: b.k, hash: b.hash}
+}
+
+// BitField returns a copy of the bits of the filter.
+func (b *Bloom) BitField() *BitField {
+	return b.bits.Clone()
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The hash function is not stored.
+func (b *Bloom) MarshalBinary() ([]byte, error) {
+	ret := append([]byte(bloomMagic), bloomVersion)
+	ret = binary.AppendUvarint(ret, uint64(b.k))
+	return b.bits.AppendBinary(ret)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The hash function
+// is kept, FNVHash on a zero Bloom. Returns ErrBadFormat or ErrChecksum if
+// data is corrupt.
+func (b *Bloom) UnmarshalBinary(data []byte) error {
+	const headerLen = len(bloomMagic) + 1
+	if len(data) < headerLen || string(data[:len(bloomMagic)]) != bloomMagic || data[2] != bloomVersion {
+		return ErrBadFormat
+	}
+	k, n := binary.Uvarint(data[headerLen:])
+	if n <= 0 || k < 1 || k > bloomMaxK {
+		return ErrBadFormat
+	}
+	bits := New(0)
+	if err := bits.UnmarshalBinary(data[headerLen+n:]); err != nil {
+		return err
+	}
+	if bits.len < 1 {
+		return ErrBadFormat
+	}
+	b.bits, b.k = bits.Mut(), int(k)
+	if b.hash == nil {
+		b.hash = FNVHash
+	}
+	return nil
+}
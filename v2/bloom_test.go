@@ -0,0 +1,81 @@
+package bitfield_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestBloom(t *testing.T) {
+	b := NewBloom(1000, 0.01)
+	assert(t, b.Len(), 9586)
+	assert(t, b.K(), 7)
+	assert(t, b.EstimatedCount(), 0.0)
+	for i := 0; i < 1000; i++ {
+		b.Add([]byte(fmt.Sprint(i)))
+	}
+	for i := 0; i < 1000; i++ {
+		assert(t, b.Test([]byte(fmt.Sprint(i))), true)
+	}
+	falsePositives := 0
+	for i := 1000; i < 11000; i++ {
+		if b.Test([]byte(fmt.Sprint(i))) {
+			falsePositives++
+		}
+	}
+	assert(t, falsePositives > 50 && falsePositives < 200, true)
+	assert(t, math.Abs(b.EstimatedCount()-1000) < 50, true)
+
+	assert(t, b.TestAndAdd([]byte("5")), true)
+	assert(t, b.TestAndAdd([]byte("new")), false)
+	assert(t, b.TestAndAdd([]byte("new")), true)
+
+	assert(t, NewBloomSize(8, 1).Add(nil).BitField().OnesCount(), 1)
+	assert(t, math.IsInf(NewBloomSize(1, 1).Add(nil).EstimatedCount(), 1), true)
+	assert(t, NewBloom(0, 0.5).Len() > 0, true)
+	assert(t, doesPanic(func() { NewBloom(-1, 0.1) }), true)
+	assert(t, doesPanic(func() { NewBloom(10, 1) }), true)
+	assert(t, doesPanic(func() { NewBloomSize(0, 1) }), true)
+}
+
+func TestBloomUnion(t *testing.T) {
+	a, b := NewBloom(100, 0.01), NewBloom(100, 0.01)
+	a.Add([]byte("a")).Add([]byte("both"))
+	b.Add([]byte("b")).Add([]byte("both"))
+	u, i := a.Union(b), a.Intersect(b)
+	assert(t, u.Test([]byte("a")), true)
+	assert(t, u.Test([]byte("b")), true)
+	assert(t, i.Test([]byte("both")), true)
+	assert(t, i.Test([]byte("a")), false)
+	assert(t, a.Test([]byte("b")), false) // a is not modified
+	assert(t, doesPanic(func() { a.Union(NewBloom(200, 0.01)) }), true)
+	assert(t, doesPanic(func() { a.Intersect(NewBloomSize(a.Len(), a.K()+1)) }), true)
+
+	// custom hash
+	constant := func([]byte) (uint64, uint64) { return 3, 0 }
+	c := NewBloomSize(64, 4).WithHash(constant).Add([]byte("x"))
+	assert(t, c.BitField().String()[:5], "00010")
+	assert(t, c.Test([]byte("y")), true)
+}
+
+func TestBloomBinary(t *testing.T) {
+	a := NewBloom(100, 0.01).Add([]byte("x"))
+	data, err := a.MarshalBinary()
+	assert(t, err, nil)
+	b := &Bloom{}
+	assert(t, b.UnmarshalBinary(data), nil)
+	assert(t, b.Len(), a.Len())
+	assert(t, b.K(), a.K())
+	assert(t, b.Test([]byte("x")), true)
+	assert(t, b.Test([]byte("y")), false)
+	assert(t, b.BitField().Equal(a.BitField()), true)
+
+	assert(t, b.UnmarshalBinary(data[:2]), ErrBadFormat)
+	assert(t, b.UnmarshalBinary(append([]byte("BL\x01\x00"), data[4:]...)), ErrBadFormat)
+	data[len(data)-5] ^= 1
+	assert(t, b.UnmarshalBinary(data), ErrChecksum)
+	empty, _ := New(0).MarshalBinary()
+	assert(t, b.UnmarshalBinary(append([]byte("BL\x01\x01"), empty...)), ErrBadFormat)
+}
@@ -0,0 +1,184 @@
+package bitfield
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// BitOrder tells how bit positions are numbered within a byte.
+type BitOrder int
+
+const (
+	// LSB0 numbers bits from the least significant one: position 0 is bit 0
+	// of the first byte. This is the layout of BitField64.
+	LSB0 BitOrder = iota
+	// MSB0 numbers bits from the most significant one: position 0 is bit 7
+	// of the first byte, as in most wire protocols.
+	MSB0
+)
+
+// Order selects the byte layout used by Bytes and FromBytes.
+//
+// The bits are split into words of Width bytes. Within a word position 0 is
+// the least significant bit of its value for LSB0 and the most significant
+// one for MSB0, then the value is written with the Word byte order.
+//
+// A nil Word is little-endian for LSB0 and big-endian for MSB0, and Width 0
+// means 8 bytes. The zero values thus number bits as a stream: position p
+// is bit p%8 of byte p/8 for LSB0 and bit 7-p%8 of byte p/8 for MSB0,
+// regardless of Width.
+//
+// For a 16 bit big-endian register with bit 0 the least significant one,
+// use Order{Word: binary.BigEndian, Width: 2}: the bytes 0x80 0x02 then set
+// positions 1 and 15. With Order{Bit: MSB0} they set positions 0 and 14.
+type Order struct {
+	Bit   BitOrder
+	Word  binary.ByteOrder
+	Width int // bytes per word: 1, 2, 4 or 8
+}
+
+func (o Order) word() binary.ByteOrder {
+	switch {
+	case o.Word != nil:
+		return o.Word
+	case o.Bit == MSB0:
+		return binary.BigEndian
+	default:
+		return binary.LittleEndian
+	}
+}
+
+// width returns the number of bytes per word, 0 if Width is invalid
+func (o Order) width() int {
+	switch o.Width {
+	case 0:
+		return 8
+	case 1, 2, 4, 8:
+		return o.Width
+	}
+	return 0
+}
+
+// stream tells if the bits are numbered as a stream, in which case the
+// bytes do not need to fill whole words.
+func (o Order) stream() bool {
+	var b [2]byte
+	o.word().PutUint16(b[:], 1)
+	return (b[0] == 1) == (o.Bit == LSB0)
+}
+
+// byteLen is the number of bytes needed to hold n bits
+func (o Order) byteLen(n int) int {
+	if o.stream() {
+		return (n + 7) / 8
+	}
+	w := o.width()
+	return (n + 8*w - 1) / (8 * w) * w
+}
+
+// putWord writes the low 8*width bits of v to b
+func (o Order) putWord(b []byte, v uint64) {
+	switch w := o.word(); len(b) {
+	case 1:
+		b[0] = byte(v)
+	case 2:
+		w.PutUint16(b, uint16(v))
+	case 4:
+		w.PutUint32(b, uint32(v))
+	default:
+		w.PutUint64(b, v)
+	}
+}
+
+func (o Order) wordAt(b []byte) uint64 {
+	switch w := o.word(); len(b) {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(w.Uint16(b))
+	case 4:
+		return uint64(w.Uint32(b))
+	default:
+		return w.Uint64(b)
+	}
+}
+
+// Bytes returns the bits as a byte slice laid out as order tells. The slice
+// has (Len()+7)/8 bytes if the bits are numbered as a stream, and whole
+// words otherwise. Unused bits at the end are zero. Panics with
+// ErrOutOfRange if order.Width is invalid.
+func (bf *BitField) Bytes(order Order) []byte {
+	width := order.width()
+	if width == 0 {
+		panic(ErrOutOfRange)
+	}
+	bitWidth := 8 * width
+	nWords := (bf.len + bitWidth - 1) / bitWidth
+	b := make([]byte, nWords*width)
+	for i := 0; i < nWords; i++ {
+		v := bf.getBits(i*bitWidth, bitWidth)
+		if order.Bit == MSB0 {
+			v = bits.Reverse64(v) >> uint(64-bitWidth)
+		}
+		order.putWord(b[i*width:(i+1)*width], v)
+	}
+	return b[:order.byteLen(bf.len)]
+}
+
+// FromBytes creates a new BitField of length n from b, laid out as order
+// tells. Bits of b beyond n are ignored.
+// Returns ErrNegativeLength if n<0, ErrOutOfRange if b holds less than n bits
+// or order.Width is invalid.
+func FromBytes(b []byte, n int, order Order) (*BitField, error) {
+	if n < 0 {
+		return nil, ErrNegativeLength
+	}
+	width := order.width()
+	if width == 0 || len(b) < order.byteLen(n) {
+		return nil, ErrOutOfRange
+	}
+	bitWidth := 8 * width
+	nWords := (n + bitWidth - 1) / bitWidth
+	buf := make([]byte, nWords*width)
+	copy(buf, b)
+	ret := New(nWords * bitWidth)
+	for i := 0; i < nWords; i++ {
+		v := order.wordAt(buf[i*width : (i+1)*width])
+		if order.Bit == MSB0 {
+			v = bits.Reverse64(v) >> uint(64-bitWidth)
+		}
+		ret.setBits(i*bitWidth, bitWidth, v)
+	}
+	ret.len = n
+	ret.data = ret.data[:1+n/64]
+	return ret.clearEnd(), nil
+}
+
+// Words returns a copy of the underlying words: bit p is bit p%64 of word
+// p/64. There are (Len()+63)/64 words, unused bits at the end are zero.
+func (bf *BitField) Words() []uint64 {
+	ret := make([]uint64, (bf.len+63)/64)
+	for i := range ret {
+		ret[i] = uint64(bf.data[i])
+	}
+	return ret
+}
+
+// FromWords creates a new BitField of length n from words laid out as
+// returned by Words. Bits beyond n are ignored.
+// Returns ErrNegativeLength if n<0, ErrOutOfRange if words hold less than n
+// bits.
+func FromWords(words []uint64, n int) (*BitField, error) {
+	if n < 0 {
+		return nil, ErrNegativeLength
+	}
+	nWords := (n + 63) / 64
+	if len(words) < nWords {
+		return nil, ErrOutOfRange
+	}
+	ret := New(n)
+	for i := 0; i < nWords; i++ {
+		ret.data[i] = BitField64(words[i])
+	}
+	return ret.clearEnd(), nil
+}
@@ -0,0 +1,104 @@
+package bitfield_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestBytes(t *testing.T) {
+	a := New(12).Set(0, 9)
+	tests := []struct {
+		order Order
+		out   string
+	}{
+		{Order{}, "[1 2]"},
+		{Order{Bit: MSB0}, "[128 64]"},
+		{Order{Word: binary.BigEndian}, "[0 0 0 0 0 0 2 1]"},
+		{Order{Bit: MSB0, Word: binary.BigEndian}, "[128 64]"},
+		{Order{Bit: MSB0, Word: binary.LittleEndian}, "[0 0 0 0 0 0 64 128]"},
+		{Order{Word: binary.BigEndian, Width: 2}, "[2 1]"},
+		{Order{Bit: MSB0, Word: binary.LittleEndian, Width: 2}, "[64 128]"},
+		{Order{Width: 1}, "[1 2]"},
+	}
+	for _, tt := range tests {
+		b := a.Bytes(tt.order)
+		assert(t, fmt.Sprint(b), tt.out)
+		c, err := FromBytes(b, a.Len(), tt.order)
+		assert(t, err, nil)
+		assert(t, c.Equal(a), true)
+	}
+
+	b := New(130).Set(0, 64, 65, 129)
+	for _, order := range []Order{
+		{}, {Bit: MSB0}, {Word: binary.BigEndian}, {Bit: MSB0, Word: binary.LittleEndian},
+		{Word: binary.BigEndian, Width: 2}, {Bit: MSB0, Word: binary.LittleEndian, Width: 4},
+	} {
+		c, err := FromBytes(b.Bytes(order), 130, order)
+		assert(t, err, nil)
+		assert(t, c.Equal(b), true)
+	}
+
+	// bits beyond n are ignored
+	c, err := FromBytes([]byte{0xff, 0xff}, 3, Order{Bit: MSB0})
+	assert(t, err, nil)
+	assert(t, c.String(), "111")
+
+	_, err = FromBytes([]byte{1}, -1, Order{})
+	assert(t, errors.Is(err, ErrNegativeLength), true)
+	_, err = FromBytes([]byte{1}, 9, Order{})
+	assert(t, errors.Is(err, ErrOutOfRange), true)
+	_, err = FromBytes([]byte{1}, 8, Order{Word: binary.BigEndian})
+	assert(t, errors.Is(err, ErrOutOfRange), true)
+	_, err = FromBytes([]byte{1}, 8, Order{Width: 3})
+	assert(t, errors.Is(err, ErrOutOfRange), true)
+	assert(t, doesPanic(func() { New(8).Bytes(Order{Width: 16}) }), true)
+
+	// a 2 byte big-endian register is not padded to a whole 8 byte word
+	c, err = FromBytes([]byte{0x80, 0x02}, 16, Order{Word: binary.BigEndian, Width: 2})
+	assert(t, err, nil)
+	assert(t, fmt.Sprint(slices.Collect(c.Ones())), "[1 15]")
+	c, err = FromBytes([]byte{0x80, 0x02}, 16, Order{Bit: MSB0, Word: binary.BigEndian})
+	assert(t, err, nil)
+	assert(t, fmt.Sprint(slices.Collect(c.Ones())), "[0 14]")
+	assert(t, fmt.Sprint(c.Bytes(Order{Bit: MSB0, Word: binary.BigEndian})), "[128 2]")
+	assert(t, len(New(0).Bytes(Order{})), 0)
+}
+
+func TestWords(t *testing.T) {
+	a := New(100).Set(0, 63, 64, 99)
+	w := a.Words()
+	assert(t, fmt.Sprint(w), fmt.Sprint([]uint64{1<<63 | 1, 1 | 1<<35}))
+	b, err := FromWords(w, 100)
+	assert(t, err, nil)
+	assert(t, b.Equal(a), true)
+
+	b, err = FromWords([]uint64{^uint64(0)}, 3)
+	assert(t, err, nil)
+	assert(t, b.String(), "111")
+	assert(t, b.OnesCount(), 3)
+
+	_, err = FromWords(nil, -1)
+	assert(t, errors.Is(err, ErrNegativeLength), true)
+	_, err = FromWords([]uint64{1}, 65)
+	assert(t, errors.Is(err, ErrOutOfRange), true)
+	assert(t, len(New(64).Words()), 1)
+}
+
+func ExampleOrder() {
+	// a 16 bit big-endian register, bit 0 being the least significant one
+	reg, _ := FromBytes([]byte{0x80, 0x02}, 16, Order{Word: binary.BigEndian, Width: 2})
+	fmt.Println(reg.Get(1), reg.Get(15))
+	// Output: true true
+}
+
+func ExampleFromBytes() {
+	// a register where bit 0 is the most significant bit of the first byte
+	bf, _ := FromBytes([]byte{0xa0}, 4, Order{Bit: MSB0})
+	fmt.Println(bf)
+	// Output: 1010
+}
@@ -0,0 +1,351 @@
+package bitfield
+
+import (
+	"io"
+	"math"
+	"math/bits"
+)
+
+// maxUnary is the largest value Unary codes, and the largest quotient of
+// Golomb codes. Longer runs of zeros are rejected as malformed.
+const maxUnary = 1 << 20
+
+// Codec is a variable-length code of unsigned integers. Decode returns
+// io.EOF if the reader is at its end, io.ErrUnexpectedEOF if it ends within
+// a code and ErrBadFormat if the code is malformed.
+type Codec interface {
+	// Encode writes the code of v. Returns ErrOutOfRange if v can not be
+	// coded.
+	Encode(w *BitWriter, v uint64) error
+	// Decode reads a code.
+	Decode(r *BitReader) (uint64, error)
+}
+
+// msbFirst converts between the low n bits of v and the value to write or
+// read so that the bits are streamed most significant first in order.
+func msbFirst(v uint64, n int, order BitOrder) uint64 {
+	if order == LSB0 {
+		return bits.Reverse64(v) >> uint(64-n)
+	}
+	return v
+}
+
+func writeMSB(w *BitWriter, v uint64, n int) {
+	w.WriteBits(msbFirst(v, n, w.order), n)
+}
+
+func readMSB(r *BitReader, n int) (uint64, error) {
+	v, err := r.ReadBits(n)
+	return msbFirst(v, n, r.order), unexpected(err)
+}
+
+// unexpected turns io.EOF into io.ErrUnexpectedEOF, for reads within a code
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// readZeros counts the zeros before the next one, at most limit of them.
+func readZeros(r *BitReader, limit int) (int, error) {
+	for n := 0; n <= limit; n++ {
+		b, err := r.ReadBool()
+		if err != nil {
+			if n > 0 {
+				err = unexpected(err)
+			}
+			return 0, err
+		}
+		if b {
+			return n, nil
+		}
+	}
+	return 0, ErrBadFormat
+}
+
+// Unary codes v as v zeros followed by a one. Values above 1<<20 are not
+// accepted.
+type Unary struct{}
+
+// Encode implements Codec.
+func (Unary) Encode(w *BitWriter, v uint64) error {
+	if v > maxUnary {
+		return ErrOutOfRange
+	}
+	for ; v >= 64; v -= 64 {
+		w.WriteBits(0, 64)
+	}
+	writeMSB(w, 1, int(v)+1)
+	return nil
+}
+
+// Decode implements Codec.
+func (Unary) Decode(r *BitReader) (uint64, error) {
+	n, err := readZeros(r, maxUnary)
+	return uint64(n), err
+}
+
+// Gamma is the Elias gamma code of positive integers: the number of bits of
+// v less one in unary, then v without its leading one.
+type Gamma struct{}
+
+// Encode implements Codec. Returns ErrOutOfRange for 0.
+func (Gamma) Encode(w *BitWriter, v uint64) error {
+	if v == 0 {
+		return ErrOutOfRange
+	}
+	n := bits.Len64(v) - 1
+	w.WriteBits(0, n)
+	writeMSB(w, v, n+1)
+	return nil
+}
+
+// Decode implements Codec.
+func (Gamma) Decode(r *BitReader) (uint64, error) {
+	n, err := readZeros(r, 63)
+	if err != nil {
+		return 0, err
+	}
+	v, err := readMSB(r, n)
+	return 1<<uint(n) | v, err
+}
+
+// Delta is the Elias delta code of positive integers: the number of bits of
+// v in Gamma code, then v without its leading one.
+type Delta struct{}
+
+// Encode implements Codec. Returns ErrOutOfRange for 0.
+func (Delta) Encode(w *BitWriter, v uint64) error {
+	if v == 0 {
+		return ErrOutOfRange
+	}
+	n := bits.Len64(v)
+	Gamma{}.Encode(w, uint64(n))
+	writeMSB(w, v, n-1)
+	return nil
+}
+
+// Decode implements Codec.
+func (Delta) Decode(r *BitReader) (uint64, error) {
+	n, err := Gamma{}.Decode(r)
+	if err != nil {
+		return 0, err
+	}
+	if n > 64 {
+		return 0, ErrBadFormat
+	}
+	v, err := readMSB(r, int(n-1))
+	return 1<<uint(n-1) | v, err
+}
+
+// Golomb is the Golomb code with parameter M: v/M in unary, then v%M in
+// truncated binary. The quotient may not exceed 1<<20.
+type Golomb struct {
+	M uint64
+}
+
+// Encode implements Codec. Returns ErrOutOfRange if M is 0.
+func (g Golomb) Encode(w *BitWriter, v uint64) error {
+	if g.M == 0 || v/g.M > maxUnary {
+		return ErrOutOfRange
+	}
+	Unary{}.Encode(w, v/g.M)
+	b := bits.Len64(g.M - 1)
+	cutoff := uint64(1)<<uint(b) - g.M
+	if rem := v % g.M; rem < cutoff {
+		writeMSB(w, rem, b-1)
+	} else {
+		writeMSB(w, rem+cutoff, b)
+	}
+	return nil
+}
+
+// Decode implements Codec. Returns ErrOutOfRange if M is 0.
+func (g Golomb) Decode(r *BitReader) (uint64, error) {
+	if g.M == 0 {
+		return 0, ErrOutOfRange
+	}
+	q, err := Unary{}.Decode(r)
+	if err != nil {
+		return 0, err
+	}
+	b := bits.Len64(g.M - 1)
+	cutoff := uint64(1)<<uint(b) - g.M
+	rem := uint64(0)
+	if b > 0 {
+		if rem, err = readMSB(r, b-1); err != nil {
+			return 0, err
+		}
+		if rem >= cutoff {
+			low, err := readMSB(r, 1)
+			if err != nil {
+				return 0, err
+			}
+			rem = (rem<<1 | low) - cutoff
+		}
+	}
+	hi, lo := bits.Mul64(q, g.M)
+	v, carry := bits.Add64(lo, rem, 0)
+	if hi != 0 || carry != 0 {
+		return 0, ErrBadFormat
+	}
+	return v, nil
+}
+
+// Rice is the Golomb code with M = 1<<K: the remainder is coded in K bits.
+type Rice struct {
+	K int
+}
+
+func (rc Rice) golomb() (Golomb, error) {
+	if rc.K < 0 || rc.K > 63 {
+		return Golomb{}, ErrOutOfRange
+	}
+	return Golomb{M: 1 << uint(rc.K)}, nil
+}
+
+// Encode implements Codec. Returns ErrOutOfRange if K is not in [0, 63].
+func (rc Rice) Encode(w *BitWriter, v uint64) error {
+	g, err := rc.golomb()
+	if err != nil {
+		return err
+	}
+	return g.Encode(w, v)
+}
+
+// Decode implements Codec. Returns ErrOutOfRange if K is not in [0, 63].
+func (rc Rice) Decode(r *BitReader) (uint64, error) {
+	g, err := rc.golomb()
+	if err != nil {
+		return 0, err
+	}
+	return g.Decode(r)
+}
+
+// fibonacci holds the Fibonacci numbers 1, 2, 3, 5... up to the largest
+// fitting in 64 bits.
+var fibonacci = func() []uint64 {
+	f := []uint64{1, 2}
+	for {
+		a, b := f[len(f)-2], f[len(f)-1]
+		if a > math.MaxUint64-b {
+			return f
+		}
+		f = append(f, a+b)
+	}
+}()
+
+// Fibonacci is the Fibonacci code of positive integers: the Zeckendorf
+// representation of v, smallest term first, ended by an extra one. A code
+// ends at the first two consecutive ones.
+type Fibonacci struct{}
+
+// Encode implements Codec. Returns ErrOutOfRange for 0.
+func (Fibonacci) Encode(w *BitWriter, v uint64) error {
+	if v == 0 {
+		return ErrOutOfRange
+	}
+	var terms [2]uint64 // len(fibonacci)+1 bits, smallest term first
+	top := -1
+	for i := len(fibonacci) - 1; i >= 0; i-- {
+		if fibonacci[i] <= v {
+			v -= fibonacci[i]
+			terms[i/64] |= 1 << uint(i%64)
+			top = max(top, i)
+		}
+	}
+	terms[(top+1)/64] |= 1 << uint((top+1)%64)
+	n := top + 2
+	pos := w.bits.len
+	w.bits.grow(pos + n)
+	w.bits.setBits(pos, min(n, 64), terms[0])
+	if n > 64 {
+		w.bits.setBits(pos+64, n-64, terms[1])
+	}
+	return nil
+}
+
+// Decode implements Codec.
+func (Fibonacci) Decode(r *BitReader) (uint64, error) {
+	v, prev := uint64(0), false
+	for i := 0; ; i++ {
+		if i > len(fibonacci) {
+			return 0, ErrBadFormat
+		}
+		b, err := r.ReadBool()
+		if err != nil {
+			if i > 0 {
+				err = unexpected(err)
+			}
+			return 0, err
+		}
+		if b && prev {
+			return v, nil
+		}
+		if b {
+			if i >= len(fibonacci) || v > math.MaxUint64-fibonacci[i] {
+				return 0, ErrBadFormat
+			}
+			v += fibonacci[i]
+		}
+		prev = b
+	}
+}
+
+// EncodeAll writes the codes of values. Stops at the first error and
+// returns it with the index of the value.
+func EncodeAll(c Codec, w *BitWriter, values []uint64) (int, error) {
+	for i, v := range values {
+		if err := c.Encode(w, v); err != nil {
+			return i, err
+		}
+	}
+	return len(values), nil
+}
+
+// DecodeAll reads n codes. Stops at the first error and returns it with the
+// values decoded so far. An io.EOF before n values is io.ErrUnexpectedEOF.
+// Returns ErrNegativeLength if n<0. The result grows as values are decoded,
+// so n may come from untrusted input.
+func DecodeAll(c Codec, r *BitReader, n int) ([]uint64, error) {
+	if n < 0 {
+		return nil, ErrNegativeLength
+	}
+	var values []uint64
+	for len(values) < n {
+		v, err := c.Decode(r)
+		if err != nil {
+			return values, unexpected(err)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// mean returns the average of values, 0 if there are none
+func mean(values []uint64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += float64(v)
+	}
+	return sum / float64(max(len(values), 1))
+}
+
+// OptimalGolomb returns the Golomb code best suited for values, assuming
+// they are geometrically distributed.
+func OptimalGolomb(values []uint64) Golomb {
+	p := 1 / (mean(values) + 1)
+	if p >= 1 {
+		return Golomb{M: 1}
+	}
+	m := math.Ceil(math.Log(2-p) / -math.Log1p(-p))
+	return Golomb{M: uint64(max(m, 1))}
+}
+
+// OptimalRice returns the Rice code best suited for values, assuming they
+// are geometrically distributed.
+func OptimalRice(values []uint64) Rice {
+	m := OptimalGolomb(values).M
+	return Rice{K: min(bits.Len64(m)-1, 63)}
+}
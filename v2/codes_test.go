@@ -0,0 +1,152 @@
+package bitfield_test
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func encoded(c Codec, v uint64) string {
+	w := NewBitWriter(LSB0)
+	if err := c.Encode(w, v); err != nil {
+		return err.Error()
+	}
+	return w.BitField().String()
+}
+
+func TestCodes(t *testing.T) {
+	assert(t, encoded(Unary{}, 0), "1")
+	assert(t, encoded(Unary{}, 3), "0001")
+	assert(t, encoded(Gamma{}, 1), "1")
+	assert(t, encoded(Gamma{}, 2), "010")
+	assert(t, encoded(Gamma{}, 5), "00101")
+	assert(t, encoded(Delta{}, 1), "1")
+	assert(t, encoded(Delta{}, 2), "0100")
+	assert(t, encoded(Delta{}, 17), "001010001")
+	assert(t, encoded(Golomb{M: 10}, 42), "00001010")
+	assert(t, encoded(Golomb{M: 10}, 9), "11111")
+	assert(t, encoded(Golomb{M: 1}, 2), "001")
+	assert(t, encoded(Rice{K: 2}, 5), "0101")
+	assert(t, encoded(Fibonacci{}, 1), "11")
+	assert(t, encoded(Fibonacci{}, 2), "011")
+	assert(t, encoded(Fibonacci{}, 4), "1011")
+	assert(t, encoded(Fibonacci{}, 11), "001011")
+
+	w := NewBitWriter(LSB0)
+	for _, c := range []Codec{Gamma{}, Delta{}, Fibonacci{}} {
+		assert(t, c.Encode(w, 0), ErrOutOfRange)
+	}
+	assert(t, Unary{}.Encode(w, 1<<21), ErrOutOfRange)
+	assert(t, Golomb{}.Encode(w, 1), ErrOutOfRange)
+	assert(t, Rice{K: 64}.Encode(w, 1), ErrOutOfRange)
+	assert(t, w.Len(), 0)
+}
+
+func TestCodesRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	values := []uint64{1, 2, 3, 1000, math.MaxUint64, 1 << 63, math.MaxUint64 - 1}
+	for i := 0; i < 200; i++ {
+		values = append(values, r.Uint64()>>uint(r.Intn(64))|1)
+	}
+	small := []uint64{0, 1, 2, 63, 64, 65, 1000, 1 << 20}
+	for i := 0; i < 200; i++ {
+		small = append(small, uint64(r.Intn(5000)))
+	}
+	for _, tc := range []struct {
+		c      Codec
+		values []uint64
+	}{
+		{Unary{}, small},
+		{Gamma{}, values},
+		{Delta{}, values},
+		{Fibonacci{}, values},
+		{Golomb{M: 1}, small},
+		{Golomb{M: 10}, small},
+		{Golomb{M: 1<<63 + 5}, values},
+		{Rice{K: 0}, small},
+		{Rice{K: 5}, small},
+		{Rice{K: 63}, values},
+	} {
+		for _, order := range []BitOrder{LSB0, MSB0} {
+			w := NewBitWriter(order)
+			n, err := EncodeAll(tc.c, w, tc.values)
+			assert(t, err, nil)
+			assert(t, n, len(tc.values))
+			got, err := DecodeAll(tc.c, NewBitReader(w.BitField(), order), len(tc.values))
+			assert(t, err, nil)
+			for i := range got {
+				assert(t, got[i], tc.values[i])
+			}
+			// truncated input
+			truncated := w.BitField().Left(w.Len() - 1)
+			got, err = DecodeAll(tc.c, NewBitReader(truncated, order), len(tc.values))
+			assert(t, errors.Is(err, io.ErrUnexpectedEOF), true)
+			assert(t, len(got), len(tc.values)-1)
+			_, err = tc.c.Decode(NewBitReader(New(0), order))
+			assert(t, err, io.EOF)
+		}
+	}
+
+	// the count is not trusted
+	w := NewBitWriter(LSB0)
+	EncodeAll(Gamma{}, w, []uint64{1, 2, 3})
+	got, err := DecodeAll(Gamma{}, NewBitReader(w.BitField(), LSB0), math.MaxInt)
+	assert(t, errors.Is(err, io.ErrUnexpectedEOF), true)
+	assert(t, len(got), 3)
+	_, err = DecodeAll(Gamma{}, NewBitReader(w.BitField(), LSB0), -1)
+	assert(t, err, ErrNegativeLength)
+}
+
+func TestCodesMalformed(t *testing.T) {
+	decode := func(c Codec, bf *BitField) error {
+		_, err := c.Decode(NewBitReader(bf, LSB0))
+		return err
+	}
+	assert(t, decode(Gamma{}, New(100).Set(64)), ErrBadFormat)
+	assert(t, decode(Unary{}, New(1<<21)), ErrBadFormat)
+	assert(t, decode(Fibonacci{}, New(200)), ErrBadFormat)
+	assert(t, decode(Fibonacci{}, New(200).Set(0, 2, 4, 199)), ErrBadFormat)
+	// gamma(65) as the length of a delta code
+	w := NewBitWriter(LSB0)
+	Gamma{}.Encode(w, 65)
+	w.WriteBits(0, 64)
+	assert(t, decode(Delta{}, w.BitField()), ErrBadFormat)
+	// quotient*M overflows
+	w = NewBitWriter(LSB0)
+	Unary{}.Encode(w, 2)
+	w.WriteBits(0, 63)
+	assert(t, decode(Golomb{M: 1 << 63}, w.BitField()), ErrBadFormat)
+	assert(t, decode(Golomb{}, New(8)), ErrOutOfRange)
+	assert(t, decode(Rice{K: -1}, New(8)), ErrOutOfRange)
+	_, err := EncodeAll(Gamma{}, NewBitWriter(LSB0), []uint64{1, 0})
+	assert(t, err, ErrOutOfRange)
+}
+
+func TestOptimalGolomb(t *testing.T) {
+	assert(t, OptimalGolomb(nil).M, uint64(1))
+	assert(t, OptimalGolomb([]uint64{0, 0}).M, uint64(1))
+	assert(t, OptimalGolomb([]uint64{9, 11}).M, uint64(7))
+	assert(t, OptimalRice([]uint64{9, 11}).K, 2)
+	assert(t, OptimalRice([]uint64{1 << 40}).K, 39)
+
+	r := rand.New(rand.NewSource(2))
+	values := make([]uint64, 1000)
+	for i := range values {
+		values[i] = uint64(r.ExpFloat64() * 100)
+	}
+	size := func(c Codec) int {
+		w := NewBitWriter(LSB0)
+		EncodeAll(c, w, values)
+		return w.Len()
+	}
+	best := size(OptimalGolomb(values))
+	for _, m := range []uint64{1, 10, 30, 150, 500} {
+		assert(t, best <= size(Golomb{M: m}), true)
+	}
+	assert(t, size(OptimalRice(values)) <= size(Rice{K: 3}), true)
+	assert(t, size(OptimalRice(values)) <= size(Rice{K: 8}), true)
+}
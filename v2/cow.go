@@ -0,0 +1,799 @@
+package bitfield
+
+import (
+	"iter"
+	"math/bits"
+	"slices"
+)
+
+// chunkWords is the unit of copying of COW: 4096 words, 256 Ki bits.
+const chunkWords = 1 << 12
+
+// chunked stores the words of a bitfield in chunks of chunkWords, so that
+// chunks can be shared between Frozen and COW values. Like BitField it has
+// 1+len/64 words and the bits beyond len are zero.
+//
+// Its exported methods are the read-only methods of BitField, shared by
+// Frozen and COW.
+type chunked struct {
+	chunks [][]BitField64
+	len    int
+	policy Policy
+}
+
+// newChunked returns the words of bf split into chunks. The words are shared,
+// not copied.
+func newChunked(bf *BitField) chunked {
+	c := chunked{len: bf.len, policy: bf.policy}
+	for i := 0; i < len(bf.data); i += chunkWords {
+		end := min(i+chunkWords, len(bf.data))
+		c.chunks = append(c.chunks, bf.data[i:end:end])
+	}
+	return c
+}
+
+// share returns a copy of c with its own chunk table, sharing the chunks.
+func (c *chunked) share() chunked {
+	return chunked{chunks: slices.Clone(c.chunks), len: c.len, policy: c.policy}
+}
+
+// words returns the number of words: 1+len/64
+func (c *chunked) words() int {
+	last := len(c.chunks) - 1
+	return last*chunkWords + len(c.chunks[last])
+}
+
+// word returns the word at index.
+func (c *chunked) word(index int) BitField64 {
+	return c.chunks[index/chunkWords][index%chunkWords]
+}
+
+// getBits is BitField.getBits on the chunks.
+func (c *chunked) getBits(pos, width int) uint64 {
+	const n = 64
+	if width == 0 {
+		return 0
+	}
+	index, offset := pos/n, uint(pos%n)
+	v := uint64(c.word(index)) >> offset
+	if offset+uint(width) > n && index+1 < c.words() {
+		v |= uint64(c.word(index+1)) << (n - offset)
+	}
+	if width < n {
+		v &= 1<<uint(width) - 1
+	}
+	return v
+}
+
+// equal tells if c and other hold the same bits. Shared chunks are not
+// compared.
+func (c *chunked) equal(other *chunked) bool {
+	if c.len != other.len {
+		return false
+	}
+	for k, chunk := range c.chunks {
+		o := other.chunks[k]
+		if &chunk[0] == &o[0] {
+			continue // shared
+		}
+		if !slices.Equal(chunk, o) {
+			return false
+		}
+	}
+	return true
+}
+
+// Len returns the number of bits.
+func (c *chunked) Len() int {
+	return c.len
+}
+
+// Policy returns the addressing policy of the BitField it was taken from.
+func (c *chunked) Policy() Policy {
+	return c.policy
+}
+
+// Get returns the bit (as a boolean) at position pos.
+func (c *chunked) Get(pos int) bool {
+	pos, err := c.policy.normalize(pos, c.len)
+	if err != nil {
+		panic(err)
+	}
+	if pos < 0 || pos >= c.len {
+		return false
+	}
+	return c.word(pos / 64).Get(pos % 64)
+}
+
+// OnesCount returns the number of bits set.
+func (c *chunked) OnesCount() int {
+	count := 0
+	for _, chunk := range c.chunks {
+		for _, w := range chunk {
+			count += w.OnesCount()
+		}
+	}
+	return count
+}
+
+// NextSet returns the position of the first set bit at or after from.
+// See BitField.NextSet.
+func (c *chunked) NextSet(from int) (int, bool) {
+	const n = 64
+	if from >= c.len {
+		return -1, false
+	}
+	from = max(from, 0)
+	index, offset := from/n, from%n
+	if w := c.word(index).Shift(-offset); w != 0 {
+		return from + w.TrailingZeros(), true
+	}
+	for index, words := index+1, c.words(); index < words; index++ {
+		if w := c.word(index); w != 0 {
+			return index*n + w.TrailingZeros(), true
+		}
+	}
+	return -1, false
+}
+
+// NextClear returns the position of the first cleared bit at or after from.
+// See BitField.NextSet.
+func (c *chunked) NextClear(from int) (int, bool) {
+	const n = 64
+	if from >= c.len {
+		return -1, false
+	}
+	from = max(from, 0)
+	index, offset := from/n, from%n
+	pos, ok := c.word(index).NextClear(offset)
+	for !ok {
+		index++
+		pos, ok = c.word(index).NextClear(0)
+	}
+	// bits beyond Len() are always zero, so the loop stops at the latest there
+	if pos = index*n + pos; pos < c.len {
+		return pos, true
+	}
+	return -1, false
+}
+
+// PrevSet returns the position of the last set bit at or before from.
+// See BitField.PrevSet.
+func (c *chunked) PrevSet(from int) (int, bool) {
+	return c.prev(from, BitField64.PrevSet)
+}
+
+// PrevClear returns the position of the last cleared bit at or before from.
+// See BitField.PrevSet.
+func (c *chunked) PrevClear(from int) (int, bool) {
+	return c.prev(from, BitField64.PrevClear)
+}
+
+// prev is PrevSet or PrevClear, with find searching a word.
+func (c *chunked) prev(from int, find func(BitField64, int) (int, bool)) (int, bool) {
+	const n = 64
+	if from < 0 || c.len == 0 {
+		return -1, false
+	}
+	from = min(from, c.len-1)
+	index, offset := from/n, from%n
+	for {
+		if pos, ok := find(c.word(index), offset); ok {
+			return index*n + pos, true
+		}
+		if index == 0 {
+			return -1, false
+		}
+		index, offset = index-1, n-1
+	}
+}
+
+// LeadingZeros returns the number of cleared bits before the highest set bit,
+// counting down from position Len()-1. Returns Len() if no bit is set.
+func (c *chunked) LeadingZeros() int {
+	return c.len - c.BitLen()
+}
+
+// TrailingZeros returns the number of cleared bits before the lowest set bit,
+// counting up from position 0. Returns Len() if no bit is set.
+func (c *chunked) TrailingZeros() int {
+	if pos, ok := c.NextSet(0); ok {
+		return pos
+	}
+	return c.len
+}
+
+// BitLen returns the position of the highest set bit plus one,
+// or 0 if no bit is set.
+func (c *chunked) BitLen() int {
+	if pos, ok := c.PrevSet(c.len - 1); ok {
+		return pos + 1
+	}
+	return 0
+}
+
+// Ones returns an iterator over the positions of the set bits,
+// in increasing order.
+func (c *chunked) Ones() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for k, chunk := range c.chunks {
+			for i, word := range chunk {
+				for w := uint64(word); w != 0; w &= w - 1 {
+					if !yield((k*chunkWords+i)*64 + bits.TrailingZeros64(w)) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// Zeros returns an iterator over the positions of the cleared bits,
+// in increasing order.
+func (c *chunked) Zeros() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		const n = 64
+		for i, words := 0, c.words(); i < words; i++ {
+			valid := c.len - i*n
+			if valid <= 0 {
+				return
+			}
+			w := ^uint64(c.word(i))
+			if valid < n {
+				w &= 1<<uint(valid) - 1
+			}
+			for ; w != 0; w &= w - 1 {
+				if !yield(i*n + bits.TrailingZeros64(w)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Runs returns an iterator over the runs of consecutive set bits as
+// half-open intervals [start, end), in increasing order.
+func (c *chunked) Runs() iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		for pos := 0; pos < c.len; {
+			start, ok := c.NextSet(pos)
+			if !ok {
+				return
+			}
+			end, ok := c.NextClear(start)
+			if !ok {
+				end = c.len
+			}
+			if !yield(start, end) {
+				return
+			}
+			pos = end
+		}
+	}
+}
+
+// CountRange returns the number of bits set in the range [start, end).
+// start and end are clamped to [0, Len()].
+func (c *chunked) CountRange(start, end int) int {
+	count := 0
+	forRange(c.len, start, end, func(index int, mask BitField64) bool {
+		count += (c.word(index) & mask).OnesCount()
+		return true
+	})
+	return count
+}
+
+// AllInRange tells if all bits in the range [start, end) are set.
+// start and end are clamped to [0, Len()]. Returns true for an empty range.
+func (c *chunked) AllInRange(start, end int) bool {
+	all := true
+	forRange(c.len, start, end, func(index int, mask BitField64) bool {
+		all = c.word(index)&mask == mask
+		return all
+	})
+	return all
+}
+
+// AnyInRange tells if any bit in the range [start, end) is set.
+// start and end are clamped to [0, Len()].
+func (c *chunked) AnyInRange(start, end int) bool {
+	found := false
+	forRange(c.len, start, end, func(index int, mask BitField64) bool {
+		found = c.word(index)&mask != 0
+		return !found
+	})
+	return found
+}
+
+// count returns the number of bits set in op of the words of c and other,
+// the shorter one read as zeros beyond its end.
+func (c *chunked) count(other *BitField, op func(a, b BitField64) BitField64) int {
+	count, own := 0, c.words()
+	for i, words := 0, max(own, len(other.data)); i < words; i++ {
+		var a, b BitField64
+		if i < own {
+			a = c.word(i)
+		}
+		if i < len(other.data) {
+			b = other.data[i]
+		}
+		count += op(a, b).OnesCount()
+	}
+	return count
+}
+
+// IsSubsetOf tells if all bits set in c are set in other as well.
+func (c *chunked) IsSubsetOf(other *BitField) bool {
+	return c.AndNotCount(other) == 0
+}
+
+// IsSupersetOf tells if all bits set in other are set in c as well.
+func (c *chunked) IsSupersetOf(other *BitField) bool {
+	return c.count(other, func(a, b BitField64) BitField64 { return b.AndNot(a) }) == 0
+}
+
+// Intersects tells if c and other have at least one set bit in common.
+func (c *chunked) Intersects(other *BitField) bool {
+	for i, words := 0, min(c.words(), len(other.data)); i < words; i++ {
+		if c.word(i).And(other.data[i]) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDisjoint tells if c and other have no set bit in common.
+func (c *chunked) IsDisjoint(other *BitField) bool {
+	return !c.Intersects(other)
+}
+
+// IntersectionCount returns the number of bits set in both c and other.
+func (c *chunked) IntersectionCount(other *BitField) int {
+	return c.count(other, BitField64.And)
+}
+
+// UnionCount returns the number of bits set in c or other.
+func (c *chunked) UnionCount(other *BitField) int {
+	return c.count(other, BitField64.Or)
+}
+
+// XorCount returns the number of bits set in exactly one of c and other.
+func (c *chunked) XorCount(other *BitField) int {
+	return c.count(other, BitField64.Xor)
+}
+
+// AndNotCount returns the number of bits set in c but not in other.
+func (c *chunked) AndNotCount(other *BitField) int {
+	return c.count(other, BitField64.AndNot)
+}
+
+// binary returns op of the words of c and other as a new bitfield.
+// Panics if lengths differ.
+func (c *chunked) binary(other *BitField, op func(a, b BitField64) BitField64) *BitField {
+	if c.len != other.len {
+		panic(ErrLengthMismatch)
+	}
+	ret := NewWithPolicy(c.len, c.policy)
+	for i := range ret.data {
+		ret.data[i] = op(c.word(i), other.data[i])
+	}
+	return ret.clearEnd()
+}
+
+// And returns c AND other as a new BitField. Panics if lengths differ.
+func (c *chunked) And(other *BitField) *BitField {
+	return c.binary(other, BitField64.And)
+}
+
+// Or returns c OR other as a new BitField. Panics if lengths differ.
+func (c *chunked) Or(other *BitField) *BitField {
+	return c.binary(other, BitField64.Or)
+}
+
+// Xor returns c XOR other as a new BitField. Panics if lengths differ.
+func (c *chunked) Xor(other *BitField) *BitField {
+	return c.binary(other, BitField64.Xor)
+}
+
+// AndNot returns c AND NOT other as a new BitField. Panics if lengths differ.
+func (c *chunked) AndNot(other *BitField) *BitField {
+	return c.binary(other, BitField64.AndNot)
+}
+
+// Not returns the inverted bits as a new BitField.
+func (c *chunked) Not() *BitField {
+	ret := NewWithPolicy(c.len, c.policy)
+	for i := range ret.data {
+		ret.data[i] = c.word(i).Not()
+	}
+	return ret.clearEnd()
+}
+
+// Mid returns count bits from position pos as a new BitField, see
+// BitField.Mid.
+func (c *chunked) Mid(pos, count int) *BitField {
+	switch {
+	case count < 0:
+		panic(ErrNegativeLength)
+	case count == 0:
+		return NewWithPolicy(0, c.policy)
+	case c.policy == Strict && (pos < 0 || pos+count > c.len):
+		panic(ErrOutOfRange)
+	}
+	count = min(count, c.len)
+	pos, err := c.policy.normalize(pos, c.len)
+	if err != nil {
+		panic(err)
+	}
+	ret := NewWithPolicy(count, c.policy)
+	if pos < 0 || pos >= c.len {
+		return ret
+	}
+	for dst, rest := 0, min(count, c.len-pos); rest > 0; {
+		w := min(rest, 64)
+		ret.setBits(dst, w, c.getBits(pos, w))
+		dst, pos, rest = dst+w, pos+w, rest-w
+	}
+	return ret
+}
+
+// Left returns the first count bits as a new BitField. Panics if count<0
+func (c *chunked) Left(count int) *BitField {
+	return c.Mid(0, count)
+}
+
+// Right returns the last count bits as a new BitField. Panics if count<0
+func (c *chunked) Right(count int) *BitField {
+	count = min(count, c.len)
+	return c.Mid(c.len-count, count)
+}
+
+// checkField panics unless [pos, pos+width) is a valid field of c
+func (c *chunked) checkField(pos, width int) {
+	if width < 0 || width > 64 || pos < 0 || pos > c.len-width {
+		panic(ErrOutOfRange)
+	}
+}
+
+// GetUint returns the width bits starting at pos, see BitField.GetUint.
+func (c *chunked) GetUint(pos, width int) uint64 {
+	return c.GetUintOrder(pos, width, LSB0)
+}
+
+// GetUintOrder returns the width bits starting at pos, see
+// BitField.GetUintOrder.
+func (c *chunked) GetUintOrder(pos, width int, order BitOrder) uint64 {
+	c.checkField(pos, width)
+	return streamValue(c.getBits(pos, width), width, order)
+}
+
+// GetInt returns the width bits starting at pos sign-extended, see
+// BitField.GetInt.
+func (c *chunked) GetInt(pos, width int) int64 {
+	v := c.GetUint(pos, width)
+	if width == 0 {
+		return 0
+	}
+	shift := uint(64 - width)
+	return int64(v<<shift) >> shift
+}
+
+// GetByte returns the 8 bits starting at pos, the least significant at pos.
+func (c *chunked) GetByte(pos int) byte {
+	return byte(c.GetUint(pos, 8))
+}
+
+// BitField returns a copy as a BitField.
+func (c *chunked) BitField() *BitField {
+	ret := NewWithPolicy(c.len, c.policy)
+	for k, chunk := range c.chunks {
+		copy(ret.data[k*chunkWords:], chunk)
+	}
+	return ret
+}
+
+// Bytes returns the bits as bytes, see BitField.Bytes.
+func (c *chunked) Bytes(order Order) []byte {
+	return c.BitField().Bytes(order)
+}
+
+// Words returns a copy of the words, see BitField.Words.
+func (c *chunked) Words() []uint64 {
+	return c.BitField().Words()
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, see
+// BitField.MarshalBinary.
+func (c *chunked) MarshalBinary() ([]byte, error) {
+	return c.BitField().MarshalBinary()
+}
+
+// AppendBinary implements encoding.BinaryAppender, see
+// BitField.AppendBinary.
+func (c *chunked) AppendBinary(b []byte) ([]byte, error) {
+	return c.BitField().AppendBinary(b)
+}
+
+// MarshalText implements encoding.TextMarshaler, see BitField.MarshalText.
+func (c *chunked) MarshalText() ([]byte, error) {
+	return c.BitField().MarshalText()
+}
+
+// MarshalJSON implements json.Marshaler, see BitField.MarshalJSON.
+func (c *chunked) MarshalJSON() ([]byte, error) {
+	return c.BitField().MarshalJSON()
+}
+
+func (c *chunked) String() string {
+	return c.BitField().String()
+}
+
+// Frozen is a read-only snapshot of a BitField. It has the read-only methods
+// of BitField and none to modify it, so it is safe to hand out and to use
+// from several goroutines at once. Methods returning a *BitField, like And or
+// Mid, return a new one. Use Clone to get a modifiable copy-on-write version
+// of it.
+type Frozen struct {
+	chunked
+}
+
+// Freeze returns a read-only snapshot of the bitfield. The snapshot shares
+// the words with bf: they are copied on the next in-place write to bf (see
+// Mut()), not before.
+func (bf *BitField) Freeze() *Frozen {
+	bf.share()
+	return &Frozen{newChunked(bf)}
+}
+
+// Equal tells if the two snapshots hold the same bits. Shared chunks are not
+// compared.
+func (f *Frozen) Equal(other *Frozen) bool {
+	return f.equal(&other.chunked)
+}
+
+// Clone returns a copy-on-write bitfield sharing the words of the snapshot.
+func (f *Frozen) Clone() *COW {
+	return &COW{chunked: f.share(), owned: New(len(f.chunks)).Mut()}
+}
+
+// COW is a copy-on-write bitfield. It shares its words with the Frozen
+// snapshot or COW it was cloned from, and copies a chunk of them (256 Ki
+// bits) on the first write to it: a Set on a large bitfield copies 32 KiB.
+//
+// It has the read-only methods of BitField, like Frozen, and the methods
+// writing bits. These modify it in-place, as if Mut() was set. Shift and
+// Rotate move all bits, they make c own all its words.
+//
+// A COW is not safe for concurrent use: Clone and Freeze modify the source
+// too, as it loses the ownership of its chunks.
+type COW struct {
+	chunked
+	owned *BitField // chunks not shared with anyone else
+}
+
+// writable returns the word at index for writing, copying its chunk if shared.
+func (c *COW) writable(index int) *BitField64 {
+	k := index / chunkWords
+	if !c.owned.Get(k) {
+		c.chunks[k] = slices.Clone(c.chunks[k])
+		c.owned.Set(k)
+	}
+	return &c.chunks[k][index%chunkWords]
+}
+
+// locate normalizes pos for writing, growing the bitfield if the policy is
+// Grow. Returns -1 if pos addresses no bit.
+func (c *COW) locate(pos int, write bool) int {
+	pos, err := c.policy.normalize(pos, c.len)
+	if err != nil {
+		panic(err)
+	}
+	if pos >= c.len {
+		if !write {
+			return -1
+		}
+		c.grow(pos + 1)
+	}
+	return pos
+}
+
+// grow extends the bitfield to newLen bits. The new bits are zeroed.
+func (c *COW) grow(newLen int) {
+	words := 1 + newLen/64
+	for have := c.words(); have < words; have = c.words() {
+		last := len(c.chunks) - 1
+		if n := len(c.chunks[last]); n < chunkWords {
+			c.writable(last * chunkWords)
+			c.chunks[last] = append(c.chunks[last], make([]BitField64, min(chunkWords-n, words-have))...)
+			continue
+		}
+		c.chunks = append(c.chunks, make([]BitField64, min(chunkWords, words-have)))
+		c.owned.Resize(len(c.chunks)).Set(-1)
+	}
+	c.len = newLen
+}
+
+// Set sets the bit(s) at position pos.
+func (c *COW) Set(pos ...int) *COW {
+	for _, p := range pos {
+		if p = c.locate(p, true); p >= 0 {
+			w := c.writable(p / 64)
+			*w = w.Set(p % 64)
+		}
+	}
+	return c
+}
+
+// Clear clears the bit(s) at position pos.
+func (c *COW) Clear(pos ...int) *COW {
+	for _, p := range pos {
+		if p = c.locate(p, false); p >= 0 {
+			w := c.writable(p / 64)
+			*w = w.Clear(p % 64)
+		}
+	}
+	return c
+}
+
+// Flip inverts the bit(s) at position pos.
+func (c *COW) Flip(pos ...int) *COW {
+	for _, p := range pos {
+		if p = c.locate(p, true); p >= 0 {
+			w := c.writable(p / 64)
+			*w = w.Flip(p % 64)
+		}
+	}
+	return c
+}
+
+// fill sets every word to w, without copying the shared chunks first.
+func (c *COW) fill(w BitField64) *COW {
+	for k, chunk := range c.chunks {
+		if !c.owned.Get(k) {
+			c.chunks[k] = make([]BitField64, len(chunk))
+			c.owned.Set(k)
+		}
+		for i := range c.chunks[k] {
+			c.chunks[k][i] = w
+		}
+	}
+	return c.clearEnd()
+}
+
+// clearEnd zeroes the bits beyond Len(), see BitField.clearEnd.
+func (c *COW) clearEnd() *COW {
+	index, mask := c.len/64, rangeMask(0, c.len%64)
+	if c.word(index)&^mask != 0 {
+		*c.writable(index) &= mask
+	}
+	return c
+}
+
+// SetAll sets all bits to 1.
+func (c *COW) SetAll() *COW {
+	return c.fill(^BitField64(0))
+}
+
+// ClearAll clears all bits.
+func (c *COW) ClearAll() *COW {
+	return c.fill(0)
+}
+
+// SetRange sets the bits in the range [start, end).
+// start and end are clamped to [0, Len()].
+func (c *COW) SetRange(start, end int) *COW {
+	forRange(c.len, start, end, func(index int, mask BitField64) bool {
+		*c.writable(index) |= mask
+		return true
+	})
+	return c
+}
+
+// ClearRange clears the bits in the range [start, end).
+// start and end are clamped to [0, Len()].
+func (c *COW) ClearRange(start, end int) *COW {
+	forRange(c.len, start, end, func(index int, mask BitField64) bool {
+		*c.writable(index) &^= mask
+		return true
+	})
+	return c
+}
+
+// FlipRange inverts the bits in the range [start, end).
+// start and end are clamped to [0, Len()].
+func (c *COW) FlipRange(start, end int) *COW {
+	forRange(c.len, start, end, func(index int, mask BitField64) bool {
+		*c.writable(index) ^= mask
+		return true
+	})
+	return c
+}
+
+// SetUint writes the low width bits of v starting at pos, see
+// BitField.SetUint.
+func (c *COW) SetUint(pos, width int, v uint64) *COW {
+	return c.SetUintOrder(pos, width, v, LSB0)
+}
+
+// SetUintOrder writes the low width bits of v starting at pos, see
+// BitField.SetUintOrder.
+func (c *COW) SetUintOrder(pos, width int, v uint64, order BitOrder) *COW {
+	c.checkField(pos, width)
+	if width == 0 {
+		return c
+	}
+	if width < 64 {
+		v &= 1<<uint(width) - 1
+	}
+	v = streamValue(v, width, order)
+	for width > 0 {
+		// at most two words, written one at a time
+		offset := pos % 64
+		n := min(width, 64-offset)
+		w := c.writable(pos / 64)
+		*w = *w&^rangeMask(offset, offset+n) | BitField64(v<<uint(offset))&rangeMask(offset, offset+n)
+		v >>= uint(n)
+		pos, width = pos+n, width-n
+	}
+	return c
+}
+
+// SetByte writes b starting at pos, the least significant bit at pos.
+func (c *COW) SetByte(pos int, b byte) *COW {
+	return c.SetUint(pos, 8, uint64(b))
+}
+
+// own replaces the content of c with bf, which it takes over.
+func (c *COW) own(bf *BitField) *COW {
+	c.chunked = newChunked(bf)
+	c.owned = New(len(c.chunks)).Mut().SetAll()
+	return c
+}
+
+// Shift shifts the bits by count, see BitField.Shift.
+func (c *COW) Shift(count int) *COW {
+	return c.own(c.BitField().Mut().Shift(count))
+}
+
+// Rotate rotates the bits by amount, see BitField.Rotate.
+func (c *COW) Rotate(amount int) *COW {
+	return c.own(c.BitField().Mut().Rotate(amount))
+}
+
+// Resize changes the length to newLen, see BitField.Resize.
+// Panics if newLen<0
+func (c *COW) Resize(newLen int) *COW {
+	switch {
+	case newLen < 0:
+		panic(ErrNegativeLength)
+	case newLen > c.len:
+		c.grow(newLen)
+		return c
+	}
+	words := 1 + newLen/64
+	last := (words - 1) / chunkWords
+	c.chunks = c.chunks[:last+1]
+	n := words - last*chunkWords
+	c.chunks[last] = c.chunks[last][:n:n]
+	c.owned.Resize(last + 1)
+	c.len = newLen
+	return c.clearEnd()
+}
+
+// Clone returns a copy-on-write copy sharing all words with c.
+func (c *COW) Clone() *COW {
+	c.owned.ClearAll()
+	return &COW{chunked: c.share(), owned: New(len(c.chunks)).Mut()}
+}
+
+// Freeze returns a read-only snapshot sharing all words with c.
+func (c *COW) Freeze() *Frozen {
+	c.owned.ClearAll()
+	return &Frozen{c.share()}
+}
+
+// Equal tells if c holds the same bits as snapshot f. Shared chunks are not
+// compared.
+func (c *COW) Equal(f *Frozen) bool {
+	return c.equal(&f.chunked)
+}
@@ -0,0 +1,209 @@
+package bitfield_test
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestFrozen(t *testing.T) {
+	a := New(1<<20).Set(0, 5, 1<<19, -1)
+	f := a.Freeze()
+	a.Mut().ClearAll() // the snapshot is independent of a
+	assert(t, f.Len(), 1<<20)
+	assert(t, f.Policy(), Modulo)
+	assert(t, f.OnesCount(), 4)
+	assert(t, f.Get(5), true)
+	assert(t, f.Get(-1), true)
+	assert(t, f.Get(6), false)
+	assert(t, fmt.Sprint(slices.Collect(f.Ones())), fmt.Sprint([]int{0, 5, 1 << 19, 1<<20 - 1}))
+	assert(t, f.BitField().Equal(New(1<<20).Set(0, 5, 1<<19, -1)), true)
+	assert(t, New(5).Set(1).Freeze().String(), "01000")
+
+	// the read-only methods of BitField
+	b := New(1<<20).Set(0, 5, 1<<19, -1)
+	assert(t, f.Equal(b.Freeze()), true)
+	next, _ := f.NextSet(6)
+	assert(t, next, 1<<19)
+	assert(t, f.CountRange(0, 6), 2)
+	assert(t, f.IsSubsetOf(b), true)
+	assert(t, f.IntersectionCount(New(1<<20).Set(5)), 1)
+	assert(t, f.And(New(1<<20).Set(5, 6)).Equal(New(1<<20).Set(5)), true)
+	assert(t, f.Left(8).String(), "10000100")
+	assert(t, f.GetUint(0, 8), uint64(0x21))
+	assert(t, slices.Equal(f.Words(), b.Words()), true)
+	text, _ := f.MarshalText()
+	assert(t, string(text), b.String())
+	f.Not()
+	assert(t, f.OnesCount(), 4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert(t, f.OnesCount(), 4)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCOW(t *testing.T) {
+	f := New(1<<20).Set(0, 1<<19).Freeze()
+	c := f.Clone()
+	assert(t, c.Len(), 1<<20)
+	assert(t, c.Equal(f), true)
+	c.Set(1).Clear(0).Flip(2)
+	assert(t, f.Get(0), true)
+	assert(t, f.Get(1), false)
+	assert(t, c.Get(0), false)
+	assert(t, c.Get(1), true)
+	assert(t, c.OnesCount(), 3)
+
+	// snapshots and clones of a COW are independent of it
+	g := c.Freeze()
+	d := c.Clone()
+	c.Set(3)
+	d.Set(4)
+	assert(t, g.OnesCount(), 3)
+	assert(t, c.OnesCount(), 4)
+	assert(t, d.OnesCount(), 4)
+	assert(t, c.Get(4), false)
+	assert(t, d.Get(3), false)
+	assert(t, g.Equal(f), false)
+	assert(t, g.Equal(New(3).Freeze()), false)
+	assert(t, c.BitField().Equal(New(1<<20).Set(1, 2, 3, 1<<19)), true)
+	assert(t, New(3).Freeze().Clone().Set(-1).String(), "001")
+
+	// policies apply
+	s := NewWithPolicy(10, Strict).Freeze().Clone()
+	assert(t, s.Policy(), Strict)
+	if !doesPanic(func() { s.Set(10) }) {
+		t.Error("should panic")
+	}
+	if !doesPanic(func() { s.Freeze().Get(-1) }) {
+		t.Error("should panic")
+	}
+	gr := NewWithPolicy(10, Grow).Freeze().Clone()
+	gr.Set(1 << 19).Flip(70000).Clear(1 << 21)
+	assert(t, gr.Len(), 1<<19+1)
+	assert(t, gr.OnesCount(), 2)
+	assert(t, gr.Get(1<<19), true)
+	assert(t, gr.BitField().Equal(NewWithPolicy(1<<19+1, Grow).Set(70000, 1<<19)), true)
+	assert(t, New(0).Freeze().Clone().Set(0).OnesCount(), 0)
+}
+
+func ExampleCOW() {
+	base := NewBitField(8).Set(1).Freeze()
+	c := base.Clone().Set(2)
+	fmt.Println(base, c)
+	// Output: 01000000 01100000
+}
+
+func TestCOWMatchesBitField(t *testing.T) {
+	// spans several chunks of 1<<18 bits
+	const size = 3<<18 + 100
+	r := rand.New(rand.NewSource(1))
+	a := New(size)
+	for i := 0; i < 5000; i++ {
+		a.Mut().Set(r.Intn(size))
+	}
+	a = a.Mut().SetRange(1<<18-10, 1<<18+10).Clone()
+	c := a.Freeze().Clone()
+	same := func() {
+		t.Helper()
+		assert(t, c.BitField().Equal(a), true)
+	}
+
+	// reads
+	for _, from := range []int{-1, 0, 1<<18 - 5, 1 << 18, size - 1, size} {
+		p, ok := a.NextSet(from)
+		q, ok2 := c.NextSet(from)
+		assert(t, [2]any{p, ok}, [2]any{q, ok2})
+		p, ok = a.NextClear(from)
+		q, ok2 = c.NextClear(from)
+		assert(t, [2]any{p, ok}, [2]any{q, ok2})
+		p, ok = a.PrevSet(from)
+		q, ok2 = c.PrevSet(from)
+		assert(t, [2]any{p, ok}, [2]any{q, ok2})
+		p, ok = a.PrevClear(from)
+		q, ok2 = c.PrevClear(from)
+		assert(t, [2]any{p, ok}, [2]any{q, ok2})
+	}
+	assert(t, c.OnesCount(), a.OnesCount())
+	assert(t, c.BitLen(), a.BitLen())
+	assert(t, c.LeadingZeros(), a.LeadingZeros())
+	assert(t, c.TrailingZeros(), a.TrailingZeros())
+	assert(t, slices.Equal(slices.Collect(c.Ones()), slices.Collect(a.Ones())), true)
+	assert(t, slices.Equal(slices.Collect(c.Zeros()), slices.Collect(a.Zeros())), true)
+	var runs, runs2 []int
+	for s, e := range a.Runs() {
+		runs = append(runs, s, e)
+	}
+	for s, e := range c.Runs() {
+		runs2 = append(runs2, s, e)
+	}
+	assert(t, slices.Equal(runs, runs2), true)
+	assert(t, c.CountRange(100, size-100), a.CountRange(100, size-100))
+	assert(t, c.AllInRange(1<<18-10, 1<<18+10), true)
+	assert(t, c.AnyInRange(-5, size+5), true)
+	assert(t, c.GetUint(1<<18-4, 8), a.GetUint(1<<18-4, 8))
+	assert(t, c.GetUintOrder(1<<18-40, 64, MSB0), a.GetUintOrder(1<<18-40, 64, MSB0))
+	assert(t, c.GetInt(1<<18-4, 8), a.GetInt(1<<18-4, 8))
+	assert(t, c.Mid(1<<18-70, 1000).Equal(a.Mid(1<<18-70, 1000)), true)
+	assert(t, c.Right(300).Equal(a.Right(300)), true)
+
+	b := New(size)
+	for i := 0; i < 5000; i++ {
+		b.Mut().Set(r.Intn(size))
+	}
+	b = b.Clone()
+	assert(t, c.And(b).Equal(a.And(b)), true)
+	assert(t, c.Or(b).Equal(a.Or(b)), true)
+	assert(t, c.Xor(b).Equal(a.Xor(b)), true)
+	assert(t, c.AndNot(b).Equal(a.AndNot(b)), true)
+	assert(t, c.Not().Equal(a.Not()), true)
+	for _, o := range []*BitField{b, b.Left(1000), New(size + 70000).Set(size + 5)} {
+		assert(t, c.IntersectionCount(o), a.IntersectionCount(o))
+		assert(t, c.UnionCount(o), a.UnionCount(o))
+		assert(t, c.XorCount(o), a.XorCount(o))
+		assert(t, c.AndNotCount(o), a.AndNotCount(o))
+		assert(t, c.Intersects(o), a.Intersects(o))
+		assert(t, c.IsSubsetOf(o), a.IsSubsetOf(o))
+		assert(t, c.IsSupersetOf(o), a.IsSupersetOf(o))
+	}
+	assert(t, c.IsSubsetOf(a.Clone().Mut().Set(0)), true)
+	assert(t, c.IsSupersetOf(a.Left(100).Resize(size)), true)
+	text, _ := c.MarshalText()
+	text2, _ := a.MarshalText()
+	assert(t, string(text), string(text2))
+
+	// writes
+	c.SetRange(1<<18-3, 1<<18+3).ClearRange(5, 70).FlipRange(1<<19, 1<<19+200)
+	a.Mut().SetRange(1<<18-3, 1<<18+3).ClearRange(5, 70).FlipRange(1<<19, 1<<19+200)
+	same()
+	c.SetUint(1<<18-4, 8, 0xa5).SetUintOrder(1<<19-40, 64, 0x0123456789abcdef, MSB0).SetByte(7, 0x81)
+	a.SetUint(1<<18-4, 8, 0xa5).SetUintOrder(1<<19-40, 64, 0x0123456789abcdef, MSB0).SetByte(7, 0x81)
+	same()
+	c.Shift(77)
+	a.Shift(77)
+	same()
+	c.Rotate(-1 << 18)
+	a.Rotate(-1 << 18)
+	same()
+	c.Resize(1<<18 + 3)
+	a.Resize(1<<18 + 3)
+	same()
+	c.Resize(size)
+	a.Resize(size)
+	same()
+	c.SetAll()
+	assert(t, c.OnesCount(), size)
+	c.ClearAll()
+	assert(t, c.OnesCount(), 0)
+	assert(t, c.Resize(64).SetAll().Freeze().String(), New(64).SetAll().String())
+}
@@ -0,0 +1,98 @@
+package bitfield
+
+// The ...Of methods write their result into the receiver (the destination)
+// regardless of Mut(), and return it. The destination may be one of the
+// operands. It takes the length of the operands; its words are reused if
+// they have room, so in a loop reusing the destination there are no
+// allocations. The policy of the destination is kept.
+
+// setLen sets the length of bf in-place, reusing its words if possible.
+// The content is left undefined: callers overwrite all words.
+func (bf *BitField) setLen(length int) {
+	bf.own()
+	words := 1 + length/64
+	if cap(bf.data) >= words {
+		bf.data = bf.data[:words]
+	} else {
+		bf.data = make([]BitField64, words)
+	}
+	bf.len = length
+}
+
+// AndOf sets bf to a AND b. Panics if the lengths of a and b differ.
+func (bf *BitField) AndOf(a, b *BitField) *BitField {
+	if a.len != b.len {
+		panic(ErrLengthMismatch)
+	}
+	bf.setLen(a.len)
+	for i := range bf.data {
+		bf.data[i] = a.data[i].And(b.data[i])
+	}
+	return bf
+}
+
+// OrOf sets bf to a OR b. Panics if the lengths of a and b differ.
+func (bf *BitField) OrOf(a, b *BitField) *BitField {
+	if a.len != b.len {
+		panic(ErrLengthMismatch)
+	}
+	bf.setLen(a.len)
+	for i := range bf.data {
+		bf.data[i] = a.data[i].Or(b.data[i])
+	}
+	return bf
+}
+
+// XorOf sets bf to a XOR b. Panics if the lengths of a and b differ.
+func (bf *BitField) XorOf(a, b *BitField) *BitField {
+	if a.len != b.len {
+		panic(ErrLengthMismatch)
+	}
+	bf.setLen(a.len)
+	for i := range bf.data {
+		bf.data[i] = a.data[i].Xor(b.data[i])
+	}
+	return bf
+}
+
+// AndNotOf sets bf to a AND NOT b. Panics if the lengths of a and b differ.
+func (bf *BitField) AndNotOf(a, b *BitField) *BitField {
+	if a.len != b.len {
+		panic(ErrLengthMismatch)
+	}
+	bf.setLen(a.len)
+	for i := range bf.data {
+		bf.data[i] = a.data[i].AndNot(b.data[i])
+	}
+	return bf
+}
+
+// NotOf sets bf to NOT a.
+func (bf *BitField) NotOf(a *BitField) *BitField {
+	bf.setLen(a.len)
+	for i := range bf.data {
+		bf.data[i] = a.data[i].Not()
+	}
+	return bf.clearEnd()
+}
+
+// ShiftOf sets bf to a shifted by count bits, see Shift.
+func (bf *BitField) ShiftOf(a *BitField, count int) *BitField {
+	bf.setLen(a.len)
+	if count <= -a.len || count >= a.len {
+		clear(bf.data)
+		return bf
+	}
+	shiftWords(bf.data, a.data, count)
+	return bf.clearEnd()
+}
+
+// mDest returns bf if Mut() is set, otherwise a new bitfield of the same
+// length and policy: the destination of the Mutable methods.
+func (bf *BitField) mDest() *BitField {
+	if bf.mutable {
+		bf.own()
+		return bf
+	}
+	return bf.newLike(bf.len)
+}
@@ -0,0 +1,58 @@
+package bitfield_test
+
+import (
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestDest(t *testing.T) {
+	a := New(130).Set(0, 64, 129)
+	b := New(130).Set(0, 1, 129)
+	dst := New(0)
+
+	assert(t, dst.AndOf(a, b).Equal(New(130).Set(0, 129)), true)
+	assert(t, dst.OrOf(a, b).Equal(New(130).Set(0, 1, 64, 129)), true)
+	assert(t, dst.XorOf(a, b).Equal(New(130).Set(1, 64)), true)
+	assert(t, dst.AndNotOf(a, b).Equal(New(130).Set(64)), true)
+	assert(t, dst.NotOf(a).OnesCount(), 127)
+	assert(t, dst.ShiftOf(a, 1).Equal(New(130).Set(1, 65)), true)
+	assert(t, dst.ShiftOf(a, 130).OnesCount(), 0)
+	assert(t, dst.ShiftOf(a, -200).OnesCount(), 0)
+
+	// operands are left intact, regardless of Mut()
+	a.Mut()
+	dst.AndOf(a, b)
+	assert(t, a.Equal(New(130).Set(0, 64, 129)), true)
+
+	// the destination takes the operands' length
+	assert(t, New(500).AndOf(New(3), New(3)).Len(), 3)
+	assert(t, New(1).NotOf(New(100)).OnesCount(), 100)
+
+	// aliasing
+	c := a.Clone()
+	c.AndOf(c, b)
+	assert(t, c.Equal(New(130).Set(0, 129)), true)
+	c.ShiftOf(c, 64)
+	assert(t, c.Equal(New(130).Set(64)), true)
+	c.ShiftOf(c, -63)
+	assert(t, c.Equal(New(130).Set(1)), true)
+	c.NotOf(c)
+	assert(t, c.OnesCount(), 129)
+
+	for _, f := range []func(){
+		func() { dst.AndOf(a, New(3)) },
+		func() { dst.OrOf(a, New(3)) },
+		func() { dst.XorOf(a, New(3)) },
+		func() { dst.AndNotOf(a, New(3)) },
+	} {
+		if !doesPanic(f) {
+			t.Error("should panic")
+		}
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		dst.AndOf(a, b).OrOf(dst, b).XorOf(a, dst).AndNotOf(dst, b).NotOf(dst).ShiftOf(dst, 3)
+	})
+	assert(t, allocs, 0.0)
+}
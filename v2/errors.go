@@ -0,0 +1,33 @@
+package bitfield
+
+import "errors"
+
+// Sentinel errors returned by the Try* and *Checked variants. The panicking
+// variants panic with the same values, so a recovered panic can be
+// inspected with errors.Is as well.
+var (
+	// ErrLengthMismatch is returned if the lengths of two bitfields differ.
+	ErrLengthMismatch = errors.New("bitfield: lengths differ")
+	// ErrNegativeLength is returned if a length or count is negative.
+	ErrNegativeLength = errors.New("bitfield: negative length")
+	// ErrOutOfRange is returned if a position is outside of the bitfield.
+	ErrOutOfRange = errors.New("bitfield: position out of range")
+	// ErrBadFormat is returned when decoding malformed input.
+	ErrBadFormat = errors.New("bitfield: malformed input")
+	// ErrChecksum is returned when the checksum of the input does not match.
+	ErrChecksum = errors.New("bitfield: checksum mismatch")
+	// ErrExhausted is returned by Allocator if no free slot is large enough.
+	ErrExhausted = errors.New("bitfield: allocator exhausted")
+	// ErrNotAllocated is returned by Allocator when freeing a free slot.
+	ErrNotAllocated = errors.New("bitfield: slot not allocated")
+	// ErrInUse is returned by Allocator when reserving an allocated slot.
+	ErrInUse = errors.New("bitfield: slot in use")
+)
+
+// must panics if err is not nil, otherwise returns bf
+func must(bf *BitField, err error) *BitField {
+	if err != nil {
+		panic(err)
+	}
+	return bf
+}
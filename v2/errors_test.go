@@ -0,0 +1,67 @@
+package bitfield_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestChecked(t *testing.T) {
+	_, err := NewChecked(-1)
+	assert(t, errors.Is(err, ErrNegativeLength), true)
+	a, err := NewChecked(10)
+	assert(t, err, nil)
+	assert(t, a.Len(), 10)
+
+	_, err = a.TryResize(-1)
+	assert(t, errors.Is(err, ErrNegativeLength), true)
+	b, err := a.TryResize(20)
+	assert(t, err, nil)
+	assert(t, b.Len(), 20)
+	assert(t, a.Len(), 10)
+
+	for _, f := range []func(*BitField) (*BitField, error){
+		a.TryAnd, a.TryOr, a.TryXor, a.TryAndNot,
+	} {
+		_, err = f(b)
+		assert(t, errors.Is(err, ErrLengthMismatch), true)
+		c, err := f(New(10).Set(1))
+		assert(t, err, nil)
+		assert(t, c.Len(), 10)
+	}
+
+	_, err = a.TryMid(2, -1)
+	assert(t, errors.Is(err, ErrNegativeLength), true)
+	_, err = a.TryLeft(-1)
+	assert(t, errors.Is(err, ErrNegativeLength), true)
+	_, err = a.TryRight(-1)
+	assert(t, errors.Is(err, ErrNegativeLength), true)
+	c, err := New(10).Set(9).TryRight(2)
+	assert(t, err, nil)
+	assert(t, c.String(), "01")
+
+	// panics carry the same sentinel errors
+	defer func() {
+		assert(t, errors.Is(recover().(error), ErrLengthMismatch), true)
+	}()
+	New(3).And(New(4))
+}
+
+func TestChecked64(t *testing.T) {
+	a := New64().SetMul(1, 62, 63)
+	_, err := a.TryMid(0, -1)
+	assert(t, errors.Is(err, ErrNegativeLength), true)
+	_, err = a.TryLeft(-1)
+	assert(t, errors.Is(err, ErrNegativeLength), true)
+	_, err = a.TryRight(-1)
+	assert(t, errors.Is(err, ErrNegativeLength), true)
+
+	b, err := a.TryMid(62, 10)
+	assert(t, err, nil)
+	assert(t, b.StringPretty(), "11")
+	b, _ = a.TryLeft(2)
+	assert(t, b.StringPretty(), "01")
+	b, _ = a.TryRight(1)
+	assert(t, b.StringPretty(), "1")
+}
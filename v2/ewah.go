@@ -0,0 +1,308 @@
+package bitfield
+
+import (
+	"iter"
+	"math/bits"
+)
+
+// Layout of an EWAH marker word: bit 0 is the running bit, bits 1-32 the
+// number of clean words of running bits, bits 33-63 the number of literal
+// words following the marker.
+const (
+	ewahMaxRun      uint64 = 1<<32 - 1
+	ewahMaxLiterals        = 1<<31 - 1
+)
+
+// EWAH is an immutable bitfield compressed with the Enhanced Word-Aligned
+// Hybrid scheme: runs of all-zero or all-one words are stored as a count,
+// other words as they are. Its size and the time of its operations are
+// proportional to the number of runs and literal words, not to Len().
+type EWAH struct {
+	words []uint64
+	len   int
+}
+
+// ewahBuilder appends words to an EWAH stream of total words, masking the
+// bits beyond length in the last one.
+type ewahBuilder struct {
+	words  []uint64
+	marker int // index of the current marker
+	n      int // number of words added
+	total  int
+	tail   uint64 // mask of the last word
+}
+
+func newEWAHBuilder(length int) *ewahBuilder {
+	b := &ewahBuilder{words: []uint64{0}, total: (length + 63) / 64, tail: ^uint64(0)}
+	if length%64 != 0 {
+		b.tail = 1<<uint(length%64) - 1
+	}
+	return b
+}
+
+func (b *ewahBuilder) run() (bool, uint64) {
+	m := b.words[b.marker]
+	return m&1 != 0, m >> 1 & ewahMaxRun
+}
+
+func (b *ewahBuilder) literals() int {
+	return int(b.words[b.marker] >> 33)
+}
+
+func (b *ewahBuilder) newMarker() {
+	b.marker = len(b.words)
+	b.words = append(b.words, 0)
+}
+
+// addClean adds count words of all bit.
+func (b *ewahBuilder) addClean(bit bool, count int) {
+	if count <= 0 {
+		return
+	}
+	if bit && b.n+count == b.total && b.tail != ^uint64(0) {
+		b.addClean(true, count-1)
+		b.addLiteral(b.tail)
+		return
+	}
+	b.n += count
+	for count > 0 {
+		running, run := b.run()
+		if b.literals() > 0 || (run > 0 && running != bit) || run == ewahMaxRun {
+			b.newMarker()
+			running, run = bit, 0
+		}
+		add := min(uint64(count), ewahMaxRun-run)
+		m := (run + add) << 1
+		if bit {
+			m |= 1
+		}
+		b.words[b.marker] = m
+		count -= int(add)
+	}
+}
+
+// addLiteral adds the word w.
+func (b *ewahBuilder) addLiteral(w uint64) {
+	if b.n == b.total-1 {
+		w &= b.tail
+	}
+	switch {
+	case w == 0:
+		b.addClean(false, 1)
+		return
+	case w == ^uint64(0):
+		b.addClean(true, 1)
+		return
+	}
+	if b.literals() == ewahMaxLiterals {
+		b.newMarker()
+	}
+	b.words[b.marker] += 1 << 33
+	b.words = append(b.words, w)
+	b.n++
+}
+
+func (b *ewahBuilder) ewah(length int) *EWAH {
+	return &EWAH{words: b.words[:len(b.words):len(b.words)], len: length}
+}
+
+// ewahCursor reads an EWAH stream run by run
+type ewahCursor struct {
+	words []uint64
+	pos   int  // index of the next word to read
+	bit   bool // the running bit
+	run   int  // clean words left
+	lits  int  // literal words left after the run
+}
+
+// load reads markers until there are words left, returns false at the end.
+func (c *ewahCursor) load() bool {
+	for c.run == 0 && c.lits == 0 {
+		if c.pos >= len(c.words) {
+			return false
+		}
+		m := c.words[c.pos]
+		c.bit, c.run, c.lits = m&1 != 0, int(m>>1&ewahMaxRun), int(m>>33)
+		c.pos++
+	}
+	return true
+}
+
+// fill returns the word of the current run
+func (c *ewahCursor) fill() uint64 {
+	if c.bit {
+		return ^uint64(0)
+	}
+	return 0
+}
+
+func (c *ewahCursor) literal() uint64 {
+	c.lits--
+	c.pos++
+	return c.words[c.pos-1]
+}
+
+// NewEWAH creates the compressed form of bf.
+func NewEWAH(bf *BitField) *EWAH {
+	b := newEWAHBuilder(bf.len)
+	for _, w := range bf.data[:(bf.len+63)/64] {
+		b.addLiteral(uint64(w))
+	}
+	return b.ewah(bf.len)
+}
+
+// Len returns the number of bits.
+func (e *EWAH) Len() int {
+	return e.len
+}
+
+// CompressedSize returns the size of the compressed data in bytes.
+func (e *EWAH) CompressedSize() int {
+	return 8 * len(e.words)
+}
+
+// OnesCount returns the number of bits set.
+func (e *EWAH) OnesCount() int {
+	count := 0
+	c := ewahCursor{words: e.words}
+	for c.load() {
+		if c.bit {
+			count += 64 * c.run
+		}
+		c.run = 0
+		for c.lits > 0 {
+			count += bits.OnesCount64(c.literal())
+		}
+	}
+	return count
+}
+
+// Ones returns an iterator over the positions of the set bits in
+// increasing order.
+func (e *EWAH) Ones() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		c := ewahCursor{words: e.words}
+		base := 0
+		for c.load() {
+			if c.bit {
+				for pos := base; pos < base+64*c.run; pos++ {
+					if !yield(pos) {
+						return
+					}
+				}
+			}
+			base += 64 * c.run
+			c.run = 0
+			for c.lits > 0 {
+				for pos := range BitField64(c.literal()).Ones() {
+					if !yield(base + pos) {
+						return
+					}
+				}
+				base += 64
+			}
+		}
+	}
+}
+
+// BitField returns the uncompressed bitfield.
+func (e *EWAH) BitField() *BitField {
+	ret := New(e.len)
+	c := ewahCursor{words: e.words}
+	i := 0
+	for c.load() {
+		if c.bit {
+			for k := i; k < i+c.run; k++ {
+				ret.data[k] = BitField64(^uint64(0))
+			}
+		}
+		i += c.run
+		c.run = 0
+		for c.lits > 0 {
+			ret.data[i] = BitField64(c.literal())
+			i++
+		}
+	}
+	ret.clearEnd()
+	return ret
+}
+
+// Not returns the complement as a new EWAH.
+func (e *EWAH) Not() *EWAH {
+	b := newEWAHBuilder(e.len)
+	c := ewahCursor{words: e.words}
+	for c.load() {
+		b.addClean(!c.bit, c.run)
+		c.run = 0
+		for c.lits > 0 {
+			b.addLiteral(^c.literal())
+		}
+	}
+	return b.ewah(e.len)
+}
+
+// op combines the two streams word by word with f, runs against runs
+// and runs against literals the result of which does not depend on the
+// literal are not expanded. Panics if lengths differ.
+func (e *EWAH) op(other *EWAH, f func(x, y uint64) uint64) *EWAH {
+	if e.len != other.len {
+		panic(ErrLengthMismatch)
+	}
+	b := newEWAHBuilder(e.len)
+	x, y := ewahCursor{words: e.words}, ewahCursor{words: other.words}
+	for x.load() && y.load() {
+		switch {
+		case x.run > 0 && y.run > 0:
+			n := min(x.run, y.run)
+			b.addClean(f(x.fill(), y.fill()) != 0, n)
+			x.run -= n
+			y.run -= n
+		case x.run > 0:
+			x.run -= runLiterals(b, &x, &y, f)
+		case y.run > 0:
+			y.run -= runLiterals(b, &y, &x, func(run, lit uint64) uint64 { return f(lit, run) })
+		default:
+			for n := min(x.lits, y.lits); n > 0; n-- {
+				b.addLiteral(f(x.literal(), y.literal()))
+			}
+		}
+	}
+	return b.ewah(e.len)
+}
+
+// runLiterals combines the run of r with the literals of l, returns the
+// number of words done.
+func runLiterals(b *ewahBuilder, r, l *ewahCursor, f func(run, lit uint64) uint64) int {
+	n := min(r.run, l.lits)
+	fill := r.fill()
+	if v := f(fill, 0); v == f(fill, ^uint64(0)) && (v == 0 || v == ^uint64(0)) {
+		b.addClean(v != 0, n)
+		l.lits -= n
+		l.pos += n
+		return n
+	}
+	for i := 0; i < n; i++ {
+		b.addLiteral(f(fill, l.literal()))
+	}
+	return n
+}
+
+// And returns e AND other as a new EWAH. Panics if lengths differ.
+func (e *EWAH) And(other *EWAH) *EWAH {
+	return e.op(other, func(x, y uint64) uint64 { return x & y })
+}
+
+// Or returns e OR other as a new EWAH. Panics if lengths differ.
+func (e *EWAH) Or(other *EWAH) *EWAH {
+	return e.op(other, func(x, y uint64) uint64 { return x | y })
+}
+
+// Xor returns e XOR other as a new EWAH. Panics if lengths differ.
+func (e *EWAH) Xor(other *EWAH) *EWAH {
+	return e.op(other, func(x, y uint64) uint64 { return x ^ y })
+}
+
+// AndNot returns e AND NOT other as a new EWAH. Panics if lengths differ.
+func (e *EWAH) AndNot(other *EWAH) *EWAH {
+	return e.op(other, func(x, y uint64) uint64 { return x &^ y })
+}
@@ -0,0 +1,77 @@
+package bitfield_test
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+// runsBitField returns a bitfield of long runs of zeros and ones with some
+// noise
+func runsBitField(r *rand.Rand, size int) *BitField {
+	bf := New(size).Mut()
+	for pos := 0; pos < size; {
+		n := r.Intn(5000)
+		if r.Intn(2) == 0 {
+			bf.SetRange(pos, pos+n)
+		}
+		pos += n
+		if r.Intn(3) == 0 && pos < size {
+			bf.Flip(pos)
+		}
+	}
+	return bf.Clone()
+}
+
+func TestEWAH(t *testing.T) {
+	assert(t, NewEWAH(New(0)).OnesCount(), 0)
+	assert(t, NewEWAH(New(0)).Not().BitField().Len(), 0)
+
+	bf := New(200).Set(0, 3, 64, 199).Mut().SetRange(70, 190)
+	e := NewEWAH(bf)
+	assert(t, e.Len(), 200)
+	assert(t, e.OnesCount(), 124)
+	assert(t, e.BitField().Equal(bf), true)
+	assert(t, fmt.Sprint(slices.Collect(NewEWAH(New(130).Set(1, 129)).Ones())), "[1 129]")
+	assert(t, e.Not().BitField().Equal(bf.Clone().Not()), true)
+	assert(t, e.Not().Not().BitField().Equal(bf), true)
+	assert(t, NewEWAH(New(100)).Not().OnesCount(), 100)
+	assert(t, NewEWAH(New(128)).Not().OnesCount(), 128)
+
+	// runs compress to a few words
+	big := New(1<<20).Mut().SetRange(1000, 1<<19)
+	assert(t, NewEWAH(big).CompressedSize() <= 5*8, true)
+	assert(t, NewEWAH(big).OnesCount(), 1<<19-1000)
+	assert(t, NewEWAH(big).Not().CompressedSize() <= 5*8, true)
+
+	r := rand.New(rand.NewSource(1))
+	for _, size := range []int{1, 63, 64, 65, 1000, 100000} {
+		bf := runsBitField(r, size)
+		e := NewEWAH(bf)
+		assert(t, e.BitField().Equal(bf), true)
+		assert(t, e.OnesCount(), bf.OnesCount())
+		assert(t, slices.Equal(slices.Collect(e.Ones()), slices.Collect(bf.Ones())), true)
+		assert(t, e.Not().BitField().Equal(bf.Clone().Not()), true)
+	}
+}
+
+func TestEWAHOps(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, size := range []int{1, 64, 100, 1000, 100000, 1 << 20} {
+		a, b := runsBitField(r, size), runsBitField(r, size)
+		if size == 100 {
+			b = randomBitField(r, size)
+		}
+		ea, eb := NewEWAH(a), NewEWAH(b)
+		assert(t, ea.And(eb).BitField().Equal(a.And(b)), true)
+		assert(t, ea.Or(eb).BitField().Equal(a.Or(b)), true)
+		assert(t, ea.Xor(eb).BitField().Equal(a.Xor(b)), true)
+		assert(t, ea.AndNot(eb).BitField().Equal(a.AndNot(b)), true)
+		assert(t, eb.AndNot(ea).OnesCount(), b.AndNotCount(a))
+		assert(t, ea.Xor(ea).CompressedSize(), 8)
+	}
+	assert(t, doesPanic(func() { NewEWAH(New(5)).And(NewEWAH(New(6))) }), true)
+}
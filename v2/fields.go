@@ -0,0 +1,65 @@
+package bitfield
+
+// Field access reads and writes integers of up to 64 bits stored at any
+// position, across word boundaries. Positions do not get the modulo
+// treatment: a field not within [0, Len()) panics with ErrOutOfRange.
+
+// checkField panics unless [pos, pos+width) is a valid field of bf
+func (bf *BitField) checkField(pos, width int) {
+	if width < 0 || width > 64 || pos < 0 || pos > bf.len-width {
+		panic(ErrOutOfRange)
+	}
+}
+
+// GetUint returns the width bits (0<=width<=64) starting at pos as an
+// unsigned integer, bit pos being its least significant bit.
+func (bf *BitField) GetUint(pos, width int) uint64 {
+	return bf.GetUintOrder(pos, width, LSB0)
+}
+
+// GetUintOrder is GetUint with bit pos being the least significant bit of
+// the result for LSB0 and the most significant one for MSB0.
+func (bf *BitField) GetUintOrder(pos, width int, order BitOrder) uint64 {
+	bf.checkField(pos, width)
+	return streamValue(bf.getBits(pos, width), width, order)
+}
+
+// SetUint writes the low width bits (0<=width<=64) of v starting at pos,
+// the least significant bit at pos. Mutable.
+func (bf *BitField) SetUint(pos, width int, v uint64) *BitField {
+	return bf.SetUintOrder(pos, width, v, LSB0)
+}
+
+// SetUintOrder is SetUint with the least significant bit of v written at
+// pos for LSB0 and the most significant one for MSB0. Mutable.
+func (bf *BitField) SetUintOrder(pos, width int, v uint64, order BitOrder) *BitField {
+	bf.checkField(pos, width)
+	ret := bf.mClone()
+	if width < 64 {
+		v &= 1<<uint(width) - 1
+	}
+	ret.setBits(pos, width, streamValue(v, width, order))
+	return ret
+}
+
+// GetInt returns the width bits (0<=width<=64) starting at pos as a two's
+// complement signed integer, bit pos being its least significant bit.
+func (bf *BitField) GetInt(pos, width int) int64 {
+	v := bf.GetUint(pos, width)
+	if width == 0 {
+		return 0
+	}
+	shift := uint(64 - width)
+	return int64(v<<shift) >> shift
+}
+
+// GetByte returns the 8 bits starting at pos, the least significant at pos.
+func (bf *BitField) GetByte(pos int) byte {
+	return byte(bf.GetUint(pos, 8))
+}
+
+// SetByte writes b starting at pos, the least significant bit at pos.
+// Mutable.
+func (bf *BitField) SetByte(pos int, b byte) *BitField {
+	return bf.SetUint(pos, 8, uint64(b))
+}
@@ -0,0 +1,70 @@
+package bitfield_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestFields(t *testing.T) {
+	a := New(200)
+	b := a.SetUint(60, 8, 0xab)
+	assert(t, a.OnesCount(), 0) // not mutable
+	assert(t, b.GetUint(60, 8), uint64(0xab))
+	assert(t, b.GetByte(60), byte(0xab))
+	assert(t, b.GetUint(60, 4), uint64(0xb))
+	assert(t, b.GetUint(64, 4), uint64(0xa))
+	assert(t, b.GetUint(0, 0), uint64(0))
+
+	a.Mut().SetUint(100, 64, math.MaxUint64).SetByte(0, 0x81)
+	assert(t, a.GetUint(100, 64), uint64(math.MaxUint64))
+	assert(t, a.GetUint(99, 64), uint64(math.MaxUint64-1))
+	assert(t, a.GetByte(0), byte(0x81))
+	assert(t, a.SetUint(8, 4, 0xff).GetUint(8, 8), uint64(0xf)) // only the low bits are written
+	assert(t, a.GetUint(192, 8), uint64(0))
+
+	assert(t, a.SetUint(20, 5, 0b10110).GetInt(20, 5), int64(-10))
+	assert(t, a.SetUint(20, 5, 0b01101).GetInt(20, 5), int64(13))
+	assert(t, a.GetInt(100, 64), int64(-1))
+	assert(t, a.GetInt(0, 0), int64(0))
+
+	assert(t, doesPanic(func() { a.GetUint(193, 8) }), true)
+	assert(t, doesPanic(func() { a.GetUint(-1, 8) }), true)
+	assert(t, doesPanic(func() { a.GetUint(0, 65) }), true)
+	assert(t, doesPanic(func() { a.SetByte(199, 1) }), true)
+	assert(t, doesPanic(func() { a.GetInt(0, -1) }), true)
+	assert(t, a.GetUint(192, 8), uint64(0))
+}
+
+func TestFieldsOrder(t *testing.T) {
+	a := New(16).SetUintOrder(2, 4, 0b1100, MSB0)
+	assert(t, a.String(), "0011000000000000")
+	assert(t, a.GetUintOrder(2, 4, MSB0), uint64(0b1100))
+	assert(t, a.GetUintOrder(2, 4, LSB0), uint64(0b0011))
+	assert(t, a.GetUint(2, 4), uint64(0b0011))
+
+	r := rand.New(rand.NewSource(1))
+	bf := New(1000).Mut()
+	for i := 0; i < 1000; i++ {
+		width := r.Intn(65)
+		pos := r.Intn(1000 - width + 1)
+		v := r.Uint64()
+		if width < 64 {
+			v &= 1<<uint(width) - 1
+		}
+		for _, order := range []BitOrder{LSB0, MSB0} {
+			bf.SetUintOrder(pos, width, v, order)
+			assert(t, bf.GetUintOrder(pos, width, order), v)
+		}
+		// MSB0 reads the bits in reverse
+		var rev uint64
+		for k := 0; k < width; k++ {
+			if bf.Get(pos + k) {
+				rev |= 1 << uint(width-1-k)
+			}
+		}
+		assert(t, rev, v)
+	}
+}
@@ -0,0 +1,213 @@
+package bitfield
+
+// summary is a tree of bitfields over the words of a bitfield: bit i of
+// levels[0] tells if word i is marked, bit i of levels[k] if word i of
+// levels[k-1] is non-zero. The top level fits in one word.
+type summary struct {
+	levels []*BitField
+}
+
+func newSummary(words int) summary {
+	var s summary
+	for {
+		level := New(words).Mut()
+		s.levels = append(s.levels, level)
+		if words <= 64 {
+			return s
+		}
+		words = len(level.data)
+	}
+}
+
+// mark sets whether word index is marked.
+func (s *summary) mark(index int, marked bool) {
+	for _, level := range s.levels {
+		w := level.data[index/64]
+		if marked {
+			level.data[index/64] = w.Set(index % 64)
+		} else {
+			level.data[index/64] = w.Clear(index % 64)
+		}
+		// upper levels only change if the word became zero or non-zero
+		if (w != 0) == (level.data[index/64] != 0) {
+			return
+		}
+		marked = level.data[index/64] != 0
+		index /= 64
+	}
+}
+
+// next returns the first marked index at or after from in levels[k].
+func (s *summary) next(k, from int) (int, bool) {
+	level := s.levels[k]
+	if from >= level.len {
+		return -1, false
+	}
+	index := from / 64
+	if w := level.data[index] &^ rangeMask(0, from%64); w != 0 {
+		return index*64 + w.TrailingZeros(), true
+	}
+	if k+1 == len(s.levels) {
+		return -1, false
+	}
+	index, ok := s.next(k+1, index+1)
+	if !ok {
+		return -1, false
+	}
+	return index*64 + level.data[index].TrailingZeros(), true
+}
+
+// Hierarchical is a bitfield with summaries of its non-empty and non-full
+// words, finding the next set or cleared bit in O(log64 n) time. Updates
+// are O(log64 n) as well. Positions outside [0, Len()) panic with
+// ErrOutOfRange.
+type Hierarchical struct {
+	bits     *BitField
+	count    int
+	nonEmpty summary
+	nonFull  summary
+}
+
+// NewHierarchical creates a Hierarchical of length bits, all cleared.
+func NewHierarchical(length int) *Hierarchical {
+	return NewHierarchicalFrom(New(length))
+}
+
+// NewHierarchicalFrom creates a Hierarchical with the content of bf.
+func NewHierarchicalFrom(bf *BitField) *Hierarchical {
+	h := &Hierarchical{
+		bits:     NewBitField(bf.len).Mut(),
+		nonEmpty: newSummary(len(bf.data)),
+		nonFull:  newSummary(len(bf.data)),
+	}
+	copy(h.bits.data, bf.data)
+	for i := range h.bits.data {
+		h.update(i)
+	}
+	h.count = h.bits.OnesCount()
+	return h
+}
+
+// wordMask returns the bits of word index within Len()
+func (h *Hierarchical) wordMask(index int) BitField64 {
+	return rangeMask(0, h.bits.len-index*64)
+}
+
+// update refreshes the summaries of word index
+func (h *Hierarchical) update(index int) {
+	w := h.bits.data[index]
+	h.nonEmpty.mark(index, w != 0)
+	h.nonFull.mark(index, w != h.wordMask(index))
+}
+
+func (h *Hierarchical) check(pos int) {
+	if pos < 0 || pos >= h.bits.len {
+		panic(ErrOutOfRange)
+	}
+}
+
+// Len returns the number of bits.
+func (h *Hierarchical) Len() int {
+	return h.bits.len
+}
+
+// OnesCount returns the number of bits set.
+func (h *Hierarchical) OnesCount() int {
+	return h.count
+}
+
+// Get returns the bit (as a boolean) at position pos.
+func (h *Hierarchical) Get(pos int) bool {
+	h.check(pos)
+	return h.bits.data[pos/64].Get(pos % 64)
+}
+
+// Set sets the bit at position pos.
+func (h *Hierarchical) Set(pos int) *Hierarchical {
+	return h.SetRange(pos, pos+1)
+}
+
+// Clear clears the bit at position pos.
+func (h *Hierarchical) Clear(pos int) *Hierarchical {
+	return h.ClearRange(pos, pos+1)
+}
+
+// SetRange sets the bits in the range [start, end). Panics with
+// ErrOutOfRange if the range is not within [0, Len()].
+func (h *Hierarchical) SetRange(start, end int) *Hierarchical {
+	return h.updateRange(start, end, func(w, mask BitField64) BitField64 { return w | mask })
+}
+
+// ClearRange clears the bits in the range [start, end). Panics with
+// ErrOutOfRange if the range is not within [0, Len()].
+func (h *Hierarchical) ClearRange(start, end int) *Hierarchical {
+	return h.updateRange(start, end, func(w, mask BitField64) BitField64 { return w &^ mask })
+}
+
+func (h *Hierarchical) updateRange(start, end int, f func(w, mask BitField64) BitField64) *Hierarchical {
+	if start < 0 || end > h.bits.len || start > end {
+		panic(ErrOutOfRange)
+	}
+	h.bits.forRange(start, end, func(index int, mask BitField64) bool {
+		w := h.bits.data[index]
+		h.bits.data[index] = f(w, mask)
+		h.count += h.bits.data[index].OnesCount() - w.OnesCount()
+		h.update(index)
+		return true
+	})
+	return h
+}
+
+// NextSet returns the position of the first set bit at or after from.
+// A from below 0 starts the search at 0, false is returned if no set bit
+// is found before Len().
+func (h *Hierarchical) NextSet(from int) (int, bool) {
+	if from >= h.bits.len {
+		return -1, false
+	}
+	from = max(from, 0)
+	index := from / 64
+	if w := h.bits.data[index] &^ rangeMask(0, from%64); w != 0 {
+		return index*64 + w.TrailingZeros(), true
+	}
+	index, ok := h.nonEmpty.next(0, index+1)
+	if !ok {
+		return -1, false
+	}
+	return index*64 + h.bits.data[index].TrailingZeros(), true
+}
+
+// NextClear returns the position of the first cleared bit at or after from.
+// See NextSet for the handling of from.
+func (h *Hierarchical) NextClear(from int) (int, bool) {
+	if from >= h.bits.len {
+		return -1, false
+	}
+	from = max(from, 0)
+	index := from / 64
+	if w := ^h.bits.data[index] & h.wordMask(index) &^ rangeMask(0, from%64); w != 0 {
+		return index*64 + w.TrailingZeros(), true
+	}
+	index, ok := h.nonFull.next(0, index+1)
+	if !ok {
+		return -1, false
+	}
+	return index*64 + (^h.bits.data[index]).TrailingZeros(), true
+}
+
+// FindFirstSet returns the position of the first set bit.
+func (h *Hierarchical) FindFirstSet() (int, bool) {
+	return h.NextSet(0)
+}
+
+// FindFirstClear returns the position of the first cleared bit.
+func (h *Hierarchical) FindFirstClear() (int, bool) {
+	return h.NextClear(0)
+}
+
+// BitField returns a copy of the content as a bitfield.
+func (h *Hierarchical) BitField() *BitField {
+	ret := New(h.bits.len)
+	copy(ret.data, h.bits.data)
+	return ret
+}
@@ -0,0 +1,97 @@
+package bitfield_test
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestHierarchical(t *testing.T) {
+	h := NewHierarchical(100)
+	assert(t, h.Len(), 100)
+	_, ok := h.FindFirstSet()
+	assert(t, ok, false)
+	pos, _ := h.FindFirstClear()
+	assert(t, pos, 0)
+	h.Set(70).SetRange(0, 64)
+	assert(t, h.Get(70), true)
+	assert(t, h.OnesCount(), 65)
+	pos, _ = h.FindFirstSet()
+	assert(t, pos, 0)
+	pos, _ = h.FindFirstClear()
+	assert(t, pos, 64)
+	pos, _ = h.NextSet(64)
+	assert(t, pos, 70)
+	h.SetRange(64, 100)
+	_, ok = h.FindFirstClear()
+	assert(t, ok, false)
+	h.Clear(99)
+	pos, _ = h.FindFirstClear()
+	assert(t, pos, 99)
+	assert(t, h.OnesCount(), 99)
+	assert(t, doesPanic(func() { h.Set(100) }), true)
+	assert(t, doesPanic(func() { h.Get(-1) }), true)
+	assert(t, doesPanic(func() { h.ClearRange(5, 101) }), true)
+	_, ok = h.NextSet(100)
+	assert(t, ok, false)
+
+	h = NewHierarchical(128).SetRange(0, 128)
+	_, ok = h.FindFirstClear()
+	assert(t, ok, false)
+	_, ok = NewHierarchical(0).FindFirstClear()
+	assert(t, ok, false)
+}
+
+func TestHierarchicalRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, size := range []int{1, 64, 4096, 4097, 1 << 20} {
+		bf := New(size).Mut()
+		h := NewHierarchicalFrom(bf)
+		check := func() {
+			t.Helper()
+			for i := 0; i < 50; i++ {
+				from := r.Intn(size)
+				want, wantOk := bf.NextSet(from)
+				got, ok := h.NextSet(from)
+				assert(t, ok, wantOk)
+				assert(t, got, want)
+				want, wantOk = bf.NextClear(from)
+				got, ok = h.NextClear(from)
+				assert(t, ok, wantOk)
+				assert(t, got, want)
+			}
+			assert(t, h.OnesCount(), bf.OnesCount())
+		}
+		check()
+		for i := 0; i < 200; i++ {
+			pos := r.Intn(size)
+			if i%2 == 0 {
+				h.Set(pos)
+				bf.Set(pos)
+			} else {
+				h.Clear(pos)
+				bf.Clear(pos)
+			}
+		}
+		check()
+		// nearly full
+		bf.SetRange(0, size).Clear(r.Intn(size), r.Intn(size))
+		h = NewHierarchicalFrom(bf)
+		check()
+		assert(t, h.BitField().Equal(bf), true)
+		start := r.Intn(size)
+		end := start + r.Intn(size-start+1)
+		h.ClearRange(start, end)
+		bf.ClearRange(start, end)
+		check()
+	}
+}
+
+func BenchmarkHierarchicalFindFirstClear(b *testing.B) {
+	h := NewHierarchicalFrom(New(1<<24).Mut().SetRange(0, 1<<24-1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.FindFirstClear()
+	}
+}
@@ -0,0 +1,114 @@
+package bitfield
+
+import (
+	"iter"
+	"math/bits"
+)
+
+// Ones returns an iterator over the positions of the set bits,
+// in increasing order.
+func (bf64 BitField64) Ones() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for w := uint64(bf64); w != 0; w &= w - 1 {
+			if !yield(bits.TrailingZeros64(w)) {
+				return
+			}
+		}
+	}
+}
+
+// Zeros returns an iterator over the positions of the cleared bits,
+// in increasing order.
+func (bf64 BitField64) Zeros() iter.Seq[int] {
+	return bf64.Not().Ones()
+}
+
+// Runs returns an iterator over the runs of consecutive set bits. Each run is
+// yielded as the half-open interval [start, end), in increasing order.
+func (bf64 BitField64) Runs() iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		const n = 64
+		for pos := 0; pos < n; {
+			start, ok := bf64.NextSet(pos)
+			if !ok {
+				return
+			}
+			end, ok := bf64.NextClear(start)
+			if !ok {
+				end = n
+			}
+			if !yield(start, end) {
+				return
+			}
+			pos = end
+		}
+	}
+}
+
+// Ones returns an iterator over the positions of the set bits,
+// in increasing order.
+//
+// The iterator works on the underlying words and reads each of them once,
+// when it gets there. If the bitfield is modified in-place (see Mut()) during
+// iteration, changes to words not reached yet are observed, changes behind the
+// current position (including the rest of the current word) are not.
+func (bf *BitField) Ones() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		const n = 64
+		for i := 0; i < len(bf.data); i++ {
+			for w := uint64(bf.data[i]); w != 0; w &= w - 1 {
+				if !yield(i*n + bits.TrailingZeros64(w)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Zeros returns an iterator over the positions of the cleared bits,
+// in increasing order. See Ones for the behaviour under modification.
+func (bf *BitField) Zeros() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		const n = 64
+		for i := 0; i < len(bf.data); i++ {
+			valid := bf.len - i*n
+			if valid <= 0 {
+				return
+			}
+			w := ^uint64(bf.data[i])
+			if valid < n {
+				w &= 1<<uint(valid) - 1
+			}
+			for ; w != 0; w &= w - 1 {
+				if !yield(i*n + bits.TrailingZeros64(w)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Runs returns an iterator over the runs of consecutive set bits. Each run is
+// yielded as the half-open interval [start, end), in increasing order.
+//
+// Every run is looked up with NextSet and NextClear after the previous one was
+// yielded, so in-place modifications (see Mut()) beyond the end of the last
+// yielded run are observed.
+func (bf *BitField) Runs() iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		for pos := 0; pos < bf.len; {
+			start, ok := bf.NextSet(pos)
+			if !ok {
+				return
+			}
+			end, ok := bf.NextClear(start)
+			if !ok {
+				end = bf.len
+			}
+			if !yield(start, end) {
+				return
+			}
+			pos = end
+		}
+	}
+}
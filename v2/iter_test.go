@@ -0,0 +1,91 @@
+package bitfield_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestIter(t *testing.T) {
+	a := New(200).Set(0, 63, 64, 65, 150, 199)
+	assert(t, fmt.Sprint(slices.Collect(a.Ones())), "[0 63 64 65 150 199]")
+
+	zeros := slices.Collect(a.Zeros())
+	assert(t, len(zeros), 194)
+	assert(t, zeros[0], 1)
+	assert(t, zeros[len(zeros)-1], 198)
+	assert(t, len(slices.Collect(New(128).Zeros())), 128)
+	assert(t, len(slices.Collect(New(0).Zeros())), 0)
+
+	var runs []string
+	for start, end := range a.Runs() {
+		runs = append(runs, fmt.Sprintf("[%d,%d)", start, end))
+	}
+	assert(t, fmt.Sprint(runs), "[[0,1) [63,66) [150,151) [199,200)]")
+
+	count := 0
+	for range New(10).SetAll().Runs() {
+		count++
+	}
+	assert(t, count, 1)
+
+	// early break
+	count = 0
+	for pos := range a.Ones() {
+		if pos > 63 {
+			break
+		}
+		count++
+	}
+	assert(t, count, 2)
+	for range a.Zeros() {
+		break
+	}
+	for range a.Runs() {
+		break
+	}
+
+	// modifying words ahead of the iterator is observed
+	b := New(130).Mut().Set(0)
+	var seen []int
+	for pos := range b.Ones() {
+		seen = append(seen, pos)
+		b.Set(129)
+	}
+	assert(t, fmt.Sprint(seen), "[0 129]")
+}
+
+func TestIter64(t *testing.T) {
+	a := New64().SetMul(0, 1, 2, 10, 63)
+	assert(t, fmt.Sprint(slices.Collect(a.Ones())), "[0 1 2 10 63]")
+	assert(t, len(slices.Collect(a.Zeros())), 59)
+
+	var runs []string
+	for start, end := range a.Runs() {
+		runs = append(runs, fmt.Sprintf("[%d,%d)", start, end))
+	}
+	assert(t, fmt.Sprint(runs), "[[0,3) [10,11) [63,64)]")
+
+	for range a.Ones() {
+		break
+	}
+	for range a.Runs() {
+		break
+	}
+	count := 0
+	for range New64().SetAll().Runs() {
+		count++
+	}
+	assert(t, count, 1)
+}
+
+func ExampleBitField_Runs() {
+	bf := NewBitField(10).Set(1, 2, 3, 7)
+	for start, end := range bf.Runs() {
+		fmt.Println(start, end)
+	}
+	// Output: 1 4
+	// 7 8
+}
@@ -0,0 +1,141 @@
+package bitfield
+
+import "iter"
+
+// PackedArray is an array of unsigned integers of a fixed bit width, stored
+// contiguously in a BitField. Values straddle word boundaries, so no bits
+// are wasted. Indexes outside [0, Len()) and values not fitting in Width()
+// bits panic with ErrOutOfRange.
+type PackedArray struct {
+	bits  *BitField
+	n     int
+	width int
+}
+
+// NewPackedArray creates an array of n zero values of width bits. Panics
+// with ErrNegativeLength if n<0 and with ErrOutOfRange if width is not in
+// [1, 64].
+func NewPackedArray(n, width int) *PackedArray {
+	if n < 0 {
+		panic(ErrNegativeLength)
+	}
+	checkWidth(width)
+	return &PackedArray{bits: New(n * width).Mut(), n: n, width: width}
+}
+
+func checkWidth(width int) {
+	if width < 1 || width > 64 {
+		panic(ErrOutOfRange)
+	}
+}
+
+// Len returns the number of values.
+func (pa *PackedArray) Len() int {
+	return pa.n
+}
+
+// Width returns the number of bits per value.
+func (pa *PackedArray) Width() int {
+	return pa.width
+}
+
+// fits tells if v fits in width bits
+func fits(v uint64, width int) bool {
+	return width == 64 || v>>uint(width) == 0
+}
+
+func (pa *PackedArray) check(i int, v uint64) {
+	if i < 0 || i >= pa.n || !fits(v, pa.width) {
+		panic(ErrOutOfRange)
+	}
+}
+
+// Get returns the value at index i.
+func (pa *PackedArray) Get(i int) uint64 {
+	pa.check(i, 0)
+	return pa.bits.getBits(i*pa.width, pa.width)
+}
+
+// Set sets the value at index i to v.
+func (pa *PackedArray) Set(i int, v uint64) *PackedArray {
+	pa.check(i, v)
+	pa.bits.setBits(i*pa.width, pa.width, v)
+	return pa
+}
+
+// Fill sets all values to v.
+func (pa *PackedArray) Fill(v uint64) *PackedArray {
+	if !fits(v, pa.width) {
+		panic(ErrOutOfRange)
+	}
+	for pos := 0; pos < pa.bits.len; pos += pa.width {
+		pa.bits.setBits(pos, pa.width, v)
+	}
+	return pa
+}
+
+// All returns an iterator over the indexes and values.
+func (pa *PackedArray) All() iter.Seq2[int, uint64] {
+	return func(yield func(int, uint64) bool) {
+		for i := 0; i < pa.n; i++ {
+			if !yield(i, pa.bits.getBits(i*pa.width, pa.width)) {
+				return
+			}
+		}
+	}
+}
+
+// Resize changes the number of values to n, new values are zero. Panics
+// with ErrNegativeLength if n<0.
+func (pa *PackedArray) Resize(n int) *PackedArray {
+	if n < 0 {
+		panic(ErrNegativeLength)
+	}
+	pa.setLen(n * pa.width)
+	pa.n = n
+	return pa
+}
+
+// setLen changes the bit length of the storage, keeping its capacity. Bits
+// beyond length are cleared.
+func (pa *PackedArray) setLen(length int) {
+	if length >= pa.bits.len {
+		pa.bits.grow(length)
+		return
+	}
+	pa.bits.len = length
+	pa.bits.data = pa.bits.data[:1+length/64]
+	pa.bits.clearEnd()
+}
+
+// SetWidth changes the width of the values to width bits, re-packing them
+// in place. Panics with ErrOutOfRange if width is not in [1, 64] or a value
+// does not fit in it, in which case the array is left unchanged.
+func (pa *PackedArray) SetWidth(width int) *PackedArray {
+	checkWidth(width)
+	old := pa.width
+	switch {
+	case width < old:
+		for _, v := range pa.All() {
+			if !fits(v, width) {
+				panic(ErrOutOfRange)
+			}
+		}
+		for i := 0; i < pa.n; i++ {
+			pa.bits.setBits(i*width, width, pa.bits.getBits(i*old, old))
+		}
+		pa.setLen(pa.n * width)
+	case width > old:
+		pa.setLen(pa.n * width)
+		for i := pa.n - 1; i >= 0; i-- {
+			pa.bits.setBits(i*width, width, pa.bits.getBits(i*old, old))
+		}
+	}
+	pa.width = width
+	return pa
+}
+
+// BitField returns a copy of the packed bits.
+func (pa *PackedArray) BitField() *BitField {
+	return pa.bits.Clone()
+}
@@ -0,0 +1,97 @@
+package bitfield_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestPackedArray(t *testing.T) {
+	pa := NewPackedArray(10, 3)
+	assert(t, pa.Len(), 10)
+	assert(t, pa.Width(), 3)
+	pa.Set(0, 5).Set(9, 7)
+	assert(t, pa.Get(0), uint64(5))
+	assert(t, pa.Get(1), uint64(0))
+	assert(t, pa.Get(9), uint64(7))
+	assert(t, pa.BitField().String(), "101000000000000000000000000111")
+	assert(t, doesPanic(func() { pa.Set(1, 8) }), true)
+	assert(t, doesPanic(func() { pa.Get(10) }), true)
+	assert(t, doesPanic(func() { pa.Get(-1) }), true)
+	assert(t, doesPanic(func() { NewPackedArray(1, 0) }), true)
+	assert(t, doesPanic(func() { NewPackedArray(1, 65) }), true)
+	assert(t, doesPanic(func() { NewPackedArray(-1, 1) }), true)
+
+	pa.Fill(6)
+	for i, v := range pa.All() {
+		assert(t, v, uint64(6))
+		if i == 3 {
+			break
+		}
+	}
+	assert(t, doesPanic(func() { pa.Fill(8) }), true)
+
+	pa.Resize(4)
+	assert(t, pa.Len(), 4)
+	assert(t, doesPanic(func() { pa.Get(4) }), true)
+	pa.Resize(30)
+	assert(t, pa.Get(3), uint64(6))
+	assert(t, pa.Get(4), uint64(0)) // new values are zero
+	assert(t, pa.Get(29), uint64(0))
+
+	wide := NewPackedArray(3, 64).Set(1, math.MaxUint64)
+	assert(t, wide.Get(1), uint64(math.MaxUint64))
+	assert(t, wide.Get(2), uint64(0))
+}
+
+func TestPackedArrayWidth(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, width := range []int{3, 7, 17, 31, 64} {
+		n := 1000
+		pa := NewPackedArray(n, width)
+		want := make([]uint64, n)
+		for i := range want {
+			want[i] = r.Uint64() >> uint(64-width)
+			pa.Set(i, want[i])
+		}
+		check := func() {
+			t.Helper()
+			assert(t, pa.Len(), n)
+			for i, v := range pa.All() {
+				assert(t, v, want[i])
+			}
+		}
+		check()
+		pa.SetWidth(min(width+13, 64))
+		check()
+		pa.SetWidth(width)
+		check()
+		if width > 1 {
+			assert(t, doesPanic(func() { pa.SetWidth(1) }), true)
+			check() // unchanged
+		}
+		// narrowing keeps values that fit
+		for i := range want {
+			want[i] &= 1
+			pa.Set(i, want[i])
+		}
+		pa.SetWidth(1)
+		check()
+		assert(t, pa.BitField().Len(), n)
+		pa.SetWidth(9)
+		check()
+	}
+}
+
+func TestPackedArrayAllocs(t *testing.T) {
+	pa := NewPackedArray(1000, 13)
+	allocs := testing.AllocsPerRun(10, func() {
+		for i := 0; i < pa.Len(); i++ {
+			pa.Set(i, pa.Get(i)+1)
+		}
+		pa.Fill(3)
+	})
+	assert(t, allocs, 0.0)
+}
@@ -0,0 +1,164 @@
+package bitfield
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// parallelMinWords is the size below which Parallel runs serially
+	parallelMinWords = 1 << 14
+	// parallelPieceWords is the unit of work handed to a goroutine; a
+	// multiple of 8 words, so pieces are aligned to 64 byte cache lines
+	parallelPieceWords = 1 << 12
+)
+
+// Parallel runs the bulk operations of a BitField on several goroutines.
+// Bitfields shorter than about a million bits are processed serially.
+//
+// Operations return the context's error if it is cancelled before they
+// complete. With Mut() set the receiver is then partially updated.
+type Parallel struct {
+	bf      *BitField
+	workers int
+	ctx     context.Context
+}
+
+// Parallel returns bf set up for parallel bulk operations on workers
+// goroutines. If workers<=0 runtime.GOMAXPROCS(0) is used.
+func (bf *BitField) Parallel(workers int) *Parallel {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &Parallel{bf: bf, workers: workers, ctx: context.Background()}
+}
+
+// WithContext returns a copy of p using ctx for cancellation.
+func (p *Parallel) WithContext(ctx context.Context) *Parallel {
+	ret := *p
+	ret.ctx = ctx
+	return &ret
+}
+
+// run calls f on consecutive pieces of [0, words) from p.workers goroutines,
+// stopping early if the context is cancelled or f returns false.
+func (p *Parallel) run(words int, f func(lo, hi int) bool) error {
+	if err := p.ctx.Err(); err != nil {
+		return err
+	}
+	if p.workers == 1 || words < parallelMinWords {
+		f(0, words)
+		return nil
+	}
+	var next atomic.Int64
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < p.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() && p.ctx.Err() == nil {
+				lo := int(next.Add(parallelPieceWords)) - parallelPieceWords
+				if lo >= words {
+					return
+				}
+				if !f(lo, min(lo+parallelPieceWords, words)) {
+					stop.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return p.ctx.Err()
+}
+
+// binary runs op on the words of p.bf and other into the Mutable destination.
+func (p *Parallel) binary(other *BitField, op func(a, b BitField64) BitField64) (*BitField, error) {
+	bf := p.bf
+	if bf.len != other.len {
+		return nil, ErrLengthMismatch
+	}
+	ret := bf.mDest()
+	err := p.run(len(bf.data), func(lo, hi int) bool {
+		for i := lo; i < hi; i++ {
+			ret.data[i] = op(bf.data[i], other.data[i])
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.clearEnd(), nil
+}
+
+// And is the parallel version of BitField.And. Mutable.
+// Returns ErrLengthMismatch if lengths differ.
+func (p *Parallel) And(other *BitField) (*BitField, error) {
+	return p.binary(other, BitField64.And)
+}
+
+// Or is the parallel version of BitField.Or. Mutable.
+// Returns ErrLengthMismatch if lengths differ.
+func (p *Parallel) Or(other *BitField) (*BitField, error) {
+	return p.binary(other, BitField64.Or)
+}
+
+// Xor is the parallel version of BitField.Xor. Mutable.
+// Returns ErrLengthMismatch if lengths differ.
+func (p *Parallel) Xor(other *BitField) (*BitField, error) {
+	return p.binary(other, BitField64.Xor)
+}
+
+// AndNot is the parallel version of BitField.AndNot. Mutable.
+// Returns ErrLengthMismatch if lengths differ.
+func (p *Parallel) AndNot(other *BitField) (*BitField, error) {
+	return p.binary(other, BitField64.AndNot)
+}
+
+// Not is the parallel version of BitField.Not. Mutable.
+func (p *Parallel) Not() (*BitField, error) {
+	return p.binary(p.bf, func(a, _ BitField64) BitField64 {
+		return a.Not()
+	})
+}
+
+// OnesCount is the parallel version of BitField.OnesCount.
+func (p *Parallel) OnesCount() (int, error) {
+	var count atomic.Int64
+	err := p.run(len(p.bf.data), func(lo, hi int) bool {
+		c := 0
+		for _, w := range p.bf.data[lo:hi] {
+			c += w.OnesCount()
+		}
+		count.Add(int64(c))
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(count.Load()), nil
+}
+
+// Equal is the parallel version of BitField.Equal. It stops at the first
+// difference found.
+func (p *Parallel) Equal(other *BitField) (bool, error) {
+	if p.bf.len != other.len {
+		return false, nil
+	}
+	var differ atomic.Bool
+	err := p.run(len(p.bf.data), func(lo, hi int) bool {
+		for i := lo; i < hi; i++ {
+			if p.bf.data[i] != other.data[i] {
+				differ.Store(true)
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return false, err
+	}
+	return !differ.Load(), nil
+}
@@ -0,0 +1,65 @@
+package bitfield_test
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, size := range []int{100, 1<<20 + 3, 5<<20 + 77} {
+		a, b := randomBitField(r, size), randomBitField(r, size)
+		for _, workers := range []int{0, 1, 3} {
+			p := a.Parallel(workers)
+			res, err := p.And(b)
+			assert(t, err, nil)
+			assert(t, res.Equal(a.And(b)), true)
+			res, _ = p.Or(b)
+			assert(t, res.Equal(a.Or(b)), true)
+			res, _ = p.Xor(b)
+			assert(t, res.Equal(a.Xor(b)), true)
+			res, _ = p.AndNot(b)
+			assert(t, res.Equal(a.AndNot(b)), true)
+			res, _ = p.Not()
+			assert(t, res.Equal(a.Not()), true)
+			count, err := p.OnesCount()
+			assert(t, err, nil)
+			assert(t, count, a.OnesCount())
+			eq, err := p.Equal(a.Clone())
+			assert(t, err, nil)
+			assert(t, eq, true)
+			eq, _ = p.Equal(a.Clone().Flip(-1))
+			assert(t, eq, false)
+			eq, _ = p.Equal(New(size - 1))
+			assert(t, eq, false)
+		}
+
+		c := a.Clone().Mut()
+		res, _ := c.Parallel(0).Xor(c)
+		assert(t, res == c, true)
+		assert(t, c.OnesCount(), 0)
+
+		_, err := a.Parallel(0).And(New(7))
+		assert(t, errors.Is(err, ErrLengthMismatch), true)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = a.Parallel(0).WithContext(ctx).OnesCount()
+		assert(t, errors.Is(err, context.Canceled), true)
+		_, err = a.Parallel(0).WithContext(ctx).Or(b)
+		assert(t, errors.Is(err, context.Canceled), true)
+		_, err = a.Parallel(0).WithContext(ctx).Equal(b)
+		assert(t, errors.Is(err, context.Canceled), true)
+	}
+}
+
+func BenchmarkParallelOnesCount(b *testing.B) {
+	a := New(1 << 28).SetAll().Parallel(0)
+	for n := 0; n < b.N; n++ {
+		a.OnesCount()
+	}
+}
@@ -0,0 +1,184 @@
+package bitfield
+
+import "slices"
+
+// Policy tells how a BitField treats positions outside of [0, Len()).
+// It applies to Set, Clear, Flip, Get, Mid and Rotate.
+type Policy int
+
+const (
+	// Modulo wraps positions around: Get(Len()) returns the 0th bit, Get(-1)
+	// the last one. This is the default policy.
+	Modulo Policy = iota
+	// Strict rejects positions outside of [0, Len()) with ErrOutOfRange: Set,
+	// Clear, Flip, Get, Mid and Rotate panic with it, TrySet, TryClear,
+	// TryFlip, TryGet, TryMid and TryRotate return it.
+	Strict
+	// Clamp moves positions below 0 to 0 and positions at or above Len() to
+	// Len()-1.
+	Clamp
+	// Grow extends the bitfield when a bit beyond Len() is set or flipped.
+	// Reading or clearing such a bit is a no-op. Negative positions panic with
+	// ErrOutOfRange.
+	Grow
+)
+
+// NewWithPolicy creates a new BitField of length len using addressing policy
+// p and returns it. Bitfields derived from it (e.g. by Clone or Mid) inherit
+// the policy. Panics if len<0
+func NewWithPolicy(len int, p Policy) *BitField {
+	ret := NewBitField(len)
+	ret.policy = p
+	return ret
+}
+
+// Policy returns the addressing policy of the bitfield.
+func (bf *BitField) Policy() Policy {
+	return bf.policy
+}
+
+// normalize maps pos into [0, Len()) according to the addressing policy.
+// Returns -1 if pos addresses no bit (the bitfield is empty). With Grow the
+// returned position can be Len() or beyond.
+func (bf *BitField) normalize(pos int) (int, error) {
+	return bf.policy.normalize(pos, bf.len)
+}
+
+// normalize maps pos into [0, length) according to the policy.
+// See (*BitField).normalize
+func (p Policy) normalize(pos, length int) (int, error) {
+	switch p {
+	case Strict:
+		if pos < 0 || pos >= length {
+			return -1, ErrOutOfRange
+		}
+	case Clamp:
+		switch {
+		case length == 0:
+			return -1, nil
+		case pos < 0:
+			pos = 0
+		case pos >= length:
+			pos = length - 1
+		}
+	case Grow:
+		if pos < 0 {
+			return -1, ErrOutOfRange
+		}
+	default:
+		if length == 0 {
+			return -1, nil
+		}
+		if pos %= length; pos < 0 {
+			pos += length
+		}
+	}
+	return pos, nil
+}
+
+// checkPos returns the error normalize gives for the first position of pos
+// it rejects.
+func (bf *BitField) checkPos(pos []int) error {
+	for _, p := range pos {
+		if _, err := bf.normalize(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TrySet is the same as Set but returns ErrOutOfRange instead of panicking
+// if the policy rejects a position. Nothing is set then. Mutable.
+func (bf *BitField) TrySet(pos ...int) (*BitField, error) {
+	if err := bf.checkPos(pos); err != nil {
+		return nil, err
+	}
+	return bf.Set(pos...), nil
+}
+
+// TryClear is the same as Clear but returns ErrOutOfRange instead of
+// panicking if the policy rejects a position. Nothing is cleared then.
+// Mutable.
+func (bf *BitField) TryClear(pos ...int) (*BitField, error) {
+	if err := bf.checkPos(pos); err != nil {
+		return nil, err
+	}
+	return bf.Clear(pos...), nil
+}
+
+// TryFlip is the same as Flip but returns ErrOutOfRange instead of panicking
+// if the policy rejects a position. Nothing is flipped then. Mutable.
+func (bf *BitField) TryFlip(pos ...int) (*BitField, error) {
+	if err := bf.checkPos(pos); err != nil {
+		return nil, err
+	}
+	return bf.Flip(pos...), nil
+}
+
+// TryGet is the same as Get but returns ErrOutOfRange instead of panicking
+// if the policy rejects pos.
+func (bf *BitField) TryGet(pos int) (bool, error) {
+	if err := bf.checkPos([]int{pos}); err != nil {
+		return false, err
+	}
+	return bf.Get(pos), nil
+}
+
+// TryRotate is the same as Rotate but returns ErrOutOfRange instead of
+// panicking if the policy rejects amount. Mutable.
+func (bf *BitField) TryRotate(amount int) (*BitField, error) {
+	if _, err := bf.rotateAmount(amount); err != nil {
+		return nil, err
+	}
+	return bf.Rotate(amount), nil
+}
+
+// locate returns where the bit at pos is stored. ok is false if pos does not
+// address a stored bit. If write is set and the policy is Grow the bitfield is
+// extended in-place to contain pos. Panics with ErrOutOfRange under Strict.
+func (bf *BitField) locate(pos int, write bool) (index, offset int, ok bool) {
+	const n = 64
+	pos, err := bf.normalize(pos)
+	if err != nil {
+		panic(err)
+	}
+	if pos < 0 {
+		return 0, 0, false
+	}
+	if pos >= bf.len {
+		if !write {
+			return 0, 0, false
+		}
+		bf.grow(pos + 1)
+	}
+	return pos / n, pos % n, true
+}
+
+// grow extends the bitfield in-place to newLen bits. The new bits are zeroed.
+func (bf *BitField) grow(newLen int) {
+	bf.own()
+	words := 1 + newLen/64
+	if extra := words - len(bf.data); extra > 0 {
+		bf.data = slices.Grow(bf.data, extra)[:words]
+		clear(bf.data[words-extra:])
+	}
+	bf.len = newLen
+}
+
+// rotateAmount normalizes the amount of Rotate to [0, Len()) according to the
+// addressing policy: Strict accepts amounts in (-Len(), Len()), Clamp limits
+// them to that range, Modulo and Grow wrap them around.
+func (bf *BitField) rotateAmount(amount int) (int, error) {
+	if bf.len == 0 {
+		return 0, nil
+	}
+	switch bf.policy {
+	case Strict:
+		if amount <= -bf.len || amount >= bf.len {
+			return 0, ErrOutOfRange
+		}
+	case Clamp:
+		amount = max(-(bf.len - 1), min(amount, bf.len-1))
+	}
+	return bf.posNormalize(amount), nil
+}
@@ -0,0 +1,100 @@
+package bitfield_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestPolicy(t *testing.T) {
+	// Modulo
+	a := New(10)
+	assert(t, a.Policy(), Modulo)
+	assert(t, a.Set(-1).Get(9), true)
+	assert(t, a.Set(-1000000001).Get(9), true)
+	assert(t, New(0).Set(3).OnesCount(), 0)
+	assert(t, New(0).Get(0), false)
+
+	// Strict
+	s := NewWithPolicy(10, Strict)
+	assert(t, s.Set(9).Get(9), true)
+	for _, f := range []func(){
+		func() { s.Set(10) },
+		func() { s.Clear(-1) },
+		func() { s.Flip(11) },
+		func() { s.Get(-1) },
+		func() { s.Mid(8, 3) },
+		func() { s.Rotate(10) },
+		func() { s.Rotate(-10) },
+		func() { NewWithPolicy(0, Strict).Get(0) },
+	} {
+		if !doesPanic(f) {
+			t.Error("should panic")
+		}
+	}
+	_, err := s.TryMid(-1, 2)
+	assert(t, errors.Is(err, ErrOutOfRange), true)
+	for _, f := range []func() (*BitField, error){
+		func() (*BitField, error) { return s.TrySet(1, 10) },
+		func() (*BitField, error) { return s.TryClear(-1) },
+		func() (*BitField, error) { return s.TryFlip(11) },
+		func() (*BitField, error) { return s.TryRotate(-10) },
+	} {
+		ret, err := f()
+		assert(t, ret == nil, true)
+		assert(t, err, ErrOutOfRange)
+	}
+	_, err = s.TryGet(10)
+	assert(t, err, ErrOutOfRange)
+	m := NewWithPolicy(10, Strict).Mut()
+	_, err = m.TrySet(2, 3, 10)
+	assert(t, err, ErrOutOfRange)
+	assert(t, m.OnesCount(), 0)
+	ret, err := s.TrySet(1, 2)
+	assert(t, err, nil)
+	assert(t, ret.CountRange(1, 3), 2)
+	got, err := ret.TryGet(2)
+	assert(t, got, true)
+	assert(t, err, nil)
+	ret, _ = ret.TryFlip(2)
+	assert(t, ret.Get(2), false)
+	ret, _ = ret.TryClear(1)
+	assert(t, ret.OnesCount(), 0)
+	ret, _ = s.Set(0).TryRotate(9)
+	assert(t, ret.Get(9), true)
+	_, err = NewWithPolicy(10, Grow).TrySet(-1)
+	assert(t, err, ErrOutOfRange)
+	assert(t, s.Set(0).Rotate(9).Get(9), true)
+	assert(t, s.Clone().Policy(), Strict)
+	assert(t, s.Mid(2, 3).Policy(), Strict)
+
+	// Clamp
+	c := NewWithPolicy(10, Clamp)
+	assert(t, c.Set(-5).Get(0), true)
+	assert(t, c.Set(50).Get(9), true)
+	assert(t, c.Set(50).Get(100), true)
+	assert(t, c.Set(50).Mid(100, 1).String(), "1")
+	assert(t, c.Set(0).Rotate(100).Get(9), true)
+	assert(t, c.Set(9).Rotate(-100).Get(0), true)
+	assert(t, NewWithPolicy(0, Clamp).Set(3).OnesCount(), 0)
+
+	// Grow
+	g := NewWithPolicy(10, Grow)
+	assert(t, g.Get(100), false)
+	assert(t, g.Clear(100).Len(), 10)
+	b := g.Set(100)
+	assert(t, g.Len(), 10)
+	assert(t, b.Len(), 101)
+	assert(t, b.Get(100), true)
+	assert(t, b.OnesCount(), 1)
+	assert(t, g.Flip(200).Len(), 201)
+	assert(t, g.Mid(50, 5).String(), "00000")
+	if !doesPanic(func() { g.Set(-1) }) {
+		t.Error("should panic")
+	}
+	g.Mut().Set(63, 64, 700)
+	assert(t, g.Len(), 701)
+	assert(t, g.OnesCount(), 3)
+	assert(t, g.Rotate(702).Get(0), true)
+}
@@ -0,0 +1,199 @@
+package bitfield
+
+import (
+	"math/bits"
+	"sort"
+)
+
+const (
+	rsBlockWords      = 8       // 512 bits: one cache line
+	rsSuperBlockWords = 1 << 10 // 65536 bits
+	rsSampleRate      = 1 << 13 // every 8192nd one/zero is sampled
+	rsBlocksPerSuper  = rsSuperBlockWords / rsBlockWords
+)
+
+// RankSelect is a succinct rank/select index over a BitField.
+//
+// It stores an absolute count of ones for every 65536 bits, a relative count
+// for every 512 bits and the superblock of every 8192nd one and zero, about
+// 4% on top of the bitfield. Rank is O(1), Select is O(log n).
+//
+// RankSelect shares the words of the bitfield it was built from, like
+// Freeze: they are copied on the next in-place write to the bitfield (see
+// Mut()), which leaves the index intact.
+type RankSelect struct {
+	data    []BitField64
+	len     int
+	ones    int
+	supers  []uint64 // ones before each superblock
+	blocks  []uint16 // ones before each block, relative to its superblock
+	samples [2][]int // superblock holding every rsSampleRate-th zero and one
+}
+
+// NewRankSelect builds a rank/select index over bf.
+func NewRankSelect(bf *BitField) *RankSelect {
+	bf.share()
+	nWords := (bf.len + 63) / 64
+	rs := &RankSelect{
+		data:   bf.data[:nWords],
+		len:    bf.len,
+		supers: make([]uint64, 0, nWords/rsSuperBlockWords+1),
+		blocks: make([]uint16, 0, nWords/rsBlockWords+1),
+	}
+	total, inSuper := 0, 0
+	for i, w := range rs.data {
+		if i%rsSuperBlockWords == 0 {
+			rs.supers = append(rs.supers, uint64(total))
+			inSuper = 0
+		}
+		if i%rsBlockWords == 0 {
+			rs.blocks = append(rs.blocks, uint16(inSuper))
+		}
+		c := w.OnesCount()
+		for k := 0; k < 2; k++ {
+			// sample the superblock of each rsSampleRate-th one (k=1) or zero (k=0)
+			before, count := total, c
+			if k == 0 {
+				before, count = i*64-total, 64-c
+				if rest := bf.len - i*64; rest < 64 {
+					count -= 64 - rest
+				}
+			}
+			for next := len(rs.samples[k]) * rsSampleRate; next < before+count; next += rsSampleRate {
+				rs.samples[k] = append(rs.samples[k], i/rsSuperBlockWords)
+			}
+		}
+		total += c
+		inSuper += c
+	}
+	rs.ones = total
+	return rs
+}
+
+// Len returns the number of bits indexed.
+func (rs *RankSelect) Len() int {
+	return rs.len
+}
+
+// OnesCount returns the number of bits set.
+func (rs *RankSelect) OnesCount() int {
+	return rs.ones
+}
+
+// Rank1 returns the number of bits set in the range [0, pos).
+// pos is clamped to [0, Len()].
+func (rs *RankSelect) Rank1(pos int) int {
+	switch {
+	case pos <= 0:
+		return 0
+	case pos >= rs.len:
+		return rs.ones
+	}
+	index, offset := pos/64, pos%64
+	r := int(rs.supers[index/rsSuperBlockWords]) + int(rs.blocks[index/rsBlockWords])
+	for i := index &^ (rsBlockWords - 1); i < index; i++ {
+		r += rs.data[i].OnesCount()
+	}
+	return r + rs.data[index].Left(offset).OnesCount()
+}
+
+// Rank0 returns the number of cleared bits in the range [0, pos).
+// pos is clamped to [0, Len()].
+func (rs *RankSelect) Rank0(pos int) int {
+	switch {
+	case pos <= 0:
+		return 0
+	case pos >= rs.len:
+		pos = rs.len
+	}
+	return pos - rs.Rank1(pos)
+}
+
+// Select1 returns the position of the k-th set bit, counting from 0.
+// Returns false if fewer than k+1 bits are set.
+func (rs *RankSelect) Select1(k int) (int, bool) {
+	if k < 0 || k >= rs.ones {
+		return -1, false
+	}
+	return rs.selectBit(k, true), true
+}
+
+// Select0 returns the position of the k-th cleared bit, counting from 0.
+// Returns false if fewer than k+1 bits are cleared.
+func (rs *RankSelect) Select0(k int) (int, bool) {
+	if k < 0 || k >= rs.len-rs.ones {
+		return -1, false
+	}
+	return rs.selectBit(k, false), true
+}
+
+// selectBit finds the k-th one (or zero if one is false), k must be in range.
+func (rs *RankSelect) selectBit(k int, one bool) int {
+	kind := 0
+	if one {
+		kind = 1
+	}
+	// ones (or zeros) before superblock s, block b
+	superRank := func(s int) int {
+		r := int(rs.supers[s])
+		if !one {
+			r = s*rsSuperBlockWords*64 - r
+		}
+		return r
+	}
+	blockRank := func(b int) int {
+		r := int(rs.blocks[b])
+		if !one {
+			r = (b%rsBlocksPerSuper)*rsBlockWords*64 - r
+		}
+		return r
+	}
+
+	// superblocks: narrowed by the samples, then binary search
+	lo := rs.samples[kind][k/rsSampleRate]
+	hi := len(rs.supers)
+	if next := k/rsSampleRate + 1; next < len(rs.samples[kind]) {
+		hi = rs.samples[kind][next] + 1
+	}
+	s := lo + sort.Search(hi-lo, func(i int) bool {
+		return superRank(lo+i) > k
+	}) - 1
+	k -= superRank(s)
+
+	// blocks within the superblock
+	lo = s * rsBlocksPerSuper
+	hi = min(lo+rsBlocksPerSuper, len(rs.blocks))
+	b := lo + sort.Search(hi-lo, func(i int) bool {
+		return blockRank(lo+i) > k
+	}) - 1
+	k -= blockRank(b)
+
+	// words within the block
+	for i := b * rsBlockWords; ; i++ {
+		w := uint64(rs.data[i])
+		if !one {
+			w = ^w
+		}
+		c := bits.OnesCount64(w)
+		if k < c {
+			return i*64 + selectInWord(w, k)
+		}
+		k -= c
+	}
+}
+
+// selectInWord returns the position of the k-th set bit of w.
+func selectInWord(w uint64, k int) int {
+	pos := 0
+	for _, width := range [...]int{32, 16, 8} {
+		if c := bits.OnesCount64(w & (1<<uint(width) - 1)); k >= c {
+			k -= c
+			w >>= uint(width)
+			pos += width
+		}
+	}
+	for ; k > 0; k-- {
+		w &= w - 1
+	}
+	return pos + bits.TrailingZeros64(w)
+}
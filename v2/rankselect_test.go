@@ -0,0 +1,80 @@
+package bitfield_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestRankSelect(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, size := range []int{0, 1, 64, 100, 513, 200000} {
+		for _, density := range []float64{0, 0.01, 0.5, 0.99, 1} {
+			bf := New(size)
+			for i := 0; i < size; i++ {
+				if r.Float64() < density {
+					bf.Mut().Set(i)
+				}
+			}
+			checkRankSelect(t, bf)
+		}
+	}
+
+	rs := NewRankSelect(New(10).Set(2))
+	assert(t, rs.Rank1(-1), 0)
+	assert(t, rs.Rank1(11), 1)
+	assert(t, rs.Rank0(100), 9)
+	assert(t, rs.Rank0(-2), 0)
+	_, ok := rs.Select1(1)
+	assert(t, ok, false)
+	_, ok = rs.Select0(-1)
+	assert(t, ok, false)
+
+	// modifying the bitfield leaves the index intact
+	bf := New(1000).Set(3, 500)
+	rs = NewRankSelect(bf)
+	bf.Mut().ClearAll().Set(7)
+	assert(t, rs.Rank1(1000), 2)
+	pos, _ := rs.Select1(1)
+	assert(t, pos, 500)
+}
+
+func checkRankSelect(t *testing.T, bf *BitField) {
+	t.Helper()
+	rs := NewRankSelect(bf)
+	assert(t, rs.Len(), bf.Len())
+	assert(t, rs.OnesCount(), bf.OnesCount())
+	ones, zeros := 0, 0
+	for pos := 0; pos < bf.Len(); pos++ {
+		if rs.Rank1(pos) != ones || rs.Rank0(pos) != zeros {
+			t.Fatalf("len %d: rank at %d is %d/%d, wants %d/%d",
+				bf.Len(), pos, rs.Rank1(pos), rs.Rank0(pos), ones, zeros)
+		}
+		if bf.Get(pos) {
+			if p, ok := rs.Select1(ones); !ok || p != pos {
+				t.Fatalf("len %d: Select1(%d) = %d,%v wants %d", bf.Len(), ones, p, ok, pos)
+			}
+			ones++
+		} else {
+			if p, ok := rs.Select0(zeros); !ok || p != pos {
+				t.Fatalf("len %d: Select0(%d) = %d,%v wants %d", bf.Len(), zeros, p, ok, pos)
+			}
+			zeros++
+		}
+	}
+	assert(t, rs.Rank1(bf.Len()), ones)
+	assert(t, rs.Rank0(bf.Len()), zeros)
+	_, ok := rs.Select1(ones)
+	assert(t, ok, false)
+	_, ok = rs.Select0(zeros)
+	assert(t, ok, false)
+}
+
+func ExampleRankSelect() {
+	rs := NewRankSelect(NewBitField(8).Set(1, 4, 6))
+	pos, _ := rs.Select1(2)
+	fmt.Println(rs.Rank1(5), pos)
+	// Output: 2 6
+}
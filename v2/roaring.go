@@ -0,0 +1,701 @@
+package bitfield
+
+import (
+	"encoding/binary"
+	"iter"
+	"math"
+	"slices"
+	"sort"
+)
+
+const (
+	containerBits = 1 << 16
+	// arrayMaxCard is the largest cardinality stored as an array container
+	arrayMaxCard = 4096
+
+	// cookies of the portable Roaring serialization format
+	serialCookieNoRun = 12346
+	serialCookie      = 12347
+	noOffsetThreshold = 4
+)
+
+type containerKind uint8
+
+const (
+	arrayContainer containerKind = iota
+	bitmapContainer
+	runContainer
+)
+
+// run16 is a run of consecutive values [start, last].
+type run16 struct {
+	start, last uint16
+}
+
+// container holds the low 16 bits of the values sharing the same high 16
+// bits. Only the field matching kind is used. Containers are never empty.
+type container struct {
+	kind   containerKind
+	array  []uint16  // sorted values, at most arrayMaxCard of them
+	bitmap *BitField // containerBits long, more than arrayMaxCard bits set
+	runs   []run16   // sorted, non-adjacent runs
+	n      int       // number of values of bitmap and run containers
+}
+
+// runsCard returns the number of values in runs
+func runsCard(runs []run16) int {
+	n := 0
+	for _, r := range runs {
+		n += int(r.last-r.start) + 1
+	}
+	return n
+}
+
+func (c *container) card() int {
+	if c.kind == arrayContainer {
+		return len(c.array)
+	}
+	return c.n
+}
+
+func (c *container) contains(v uint16) bool {
+	switch c.kind {
+	case bitmapContainer:
+		return c.bitmap.Get(int(v))
+	case runContainer:
+		i := sort.Search(len(c.runs), func(i int) bool { return c.runs[i].last >= v })
+		return i < len(c.runs) && c.runs[i].start <= v
+	default:
+		_, found := slices.BinarySearch(c.array, v)
+		return found
+	}
+}
+
+// equal tells if c and other hold the same values. Array containers are
+// compared as they are, other kinds as bitfields.
+func (c *container) equal(other *container) bool {
+	switch {
+	case c.card() != other.card():
+		return false
+	case c.kind == arrayContainer && other.kind == arrayContainer:
+		return slices.Equal(c.array, other.array)
+	case c.kind == runContainer && other.kind == runContainer:
+		return slices.Equal(c.runs, other.runs)
+	}
+	return c.toBitField().Equal(other.toBitField())
+}
+
+// toBitField returns the content as a bitfield, which must not be modified:
+// it is the bitmap itself for bitmap containers.
+func (c *container) toBitField() *BitField {
+	switch c.kind {
+	case bitmapContainer:
+		return c.bitmap
+	case runContainer:
+		ret := New(containerBits).Mut()
+		for _, r := range c.runs {
+			ret.SetRange(int(r.start), int(r.last)+1)
+		}
+		return ret
+	default:
+		ret := New(containerBits)
+		for _, v := range c.array {
+			ret.data[v/64] = ret.data[v/64].Set(int(v % 64))
+		}
+		return ret
+	}
+}
+
+// containerOf makes a container of the bits in bf, which is taken over.
+// Returns nil if no bit is set.
+func containerOf(bf *BitField) *container {
+	switch card := bf.OnesCount(); {
+	case card == 0:
+		return nil
+	case card > arrayMaxCard:
+		bf.mutable = false
+		return &container{kind: bitmapContainer, bitmap: bf, n: card}
+	default:
+		array := make([]uint16, 0, card)
+		for v := range bf.Ones() {
+			array = append(array, uint16(v))
+		}
+		return &container{kind: arrayContainer, array: array}
+	}
+}
+
+// normalize converts the container to the kind its cardinality requires,
+// returns nil if it is empty. Run containers are kept.
+func (c *container) normalize() *container {
+	switch c.kind {
+	case runContainer:
+		if len(c.runs) == 0 {
+			return nil
+		}
+		return c
+	case bitmapContainer:
+		if c.n > arrayMaxCard {
+			return c
+		}
+		return containerOf(c.bitmap)
+	default:
+		if len(c.array) == 0 {
+			return nil
+		}
+		if len(c.array) > arrayMaxCard {
+			return containerOf(c.toBitField())
+		}
+		return c
+	}
+}
+
+func (c *container) clone() *container {
+	return &container{
+		kind:   c.kind,
+		array:  slices.Clone(c.array),
+		bitmap: c.bitmapClone(),
+		runs:   slices.Clone(c.runs),
+		n:      c.n,
+	}
+}
+
+func (c *container) bitmapClone() *BitField {
+	if c.bitmap == nil {
+		return nil
+	}
+	return c.bitmap.Clone()
+}
+
+// add adds v, returns the container to use from now on.
+func (c *container) add(v uint16) *container {
+	switch c.kind {
+	case bitmapContainer:
+		if w := c.bitmap.data[v/64]; !w.Get(int(v % 64)) {
+			c.bitmap.data[v/64] = w.Set(int(v % 64))
+			c.n++
+		}
+		return c
+	case runContainer:
+		if c.contains(v) {
+			return c
+		}
+		return containerOf(c.toBitField().Set(int(v)))
+	default:
+		i, found := slices.BinarySearch(c.array, v)
+		if found {
+			return c
+		}
+		c.array = slices.Insert(c.array, i, v)
+		return c.normalize()
+	}
+}
+
+// remove removes v, returns the container to use from now on (nil if empty).
+func (c *container) remove(v uint16) *container {
+	switch c.kind {
+	case bitmapContainer:
+		if w := c.bitmap.data[v/64]; w.Get(int(v % 64)) {
+			c.bitmap.data[v/64] = w.Clear(int(v % 64))
+			c.n--
+		}
+		return c.normalize()
+	case runContainer:
+		if !c.contains(v) {
+			return c
+		}
+		return containerOf(c.toBitField().Clear(int(v)))
+	default:
+		i, found := slices.BinarySearch(c.array, v)
+		if found {
+			c.array = slices.Delete(c.array, i, i+1)
+		}
+		return c.normalize()
+	}
+}
+
+// each calls yield with each value in increasing order, stops if it returns
+// false. Returns false if stopped.
+func (c *container) each(yield func(uint16) bool) bool {
+	switch c.kind {
+	case bitmapContainer:
+		for v := range c.bitmap.Ones() {
+			if !yield(uint16(v)) {
+				return false
+			}
+		}
+	case runContainer:
+		for _, r := range c.runs {
+			for v := int(r.start); v <= int(r.last); v++ {
+				if !yield(uint16(v)) {
+					return false
+				}
+			}
+		}
+	default:
+		for _, v := range c.array {
+			if !yield(v) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// runsOf returns the runs of the container
+func (c *container) runsOf() []run16 {
+	if c.kind == runContainer {
+		return c.runs
+	}
+	var runs []run16
+	if c.kind == bitmapContainer {
+		for start, end := range c.bitmap.Runs() {
+			runs = append(runs, run16{uint16(start), uint16(end - 1)})
+		}
+		return runs
+	}
+	for _, v := range c.array {
+		if n := len(runs); n > 0 && uint32(runs[n-1].last)+1 == uint32(v) {
+			runs[n-1].last = v
+			continue
+		}
+		runs = append(runs, run16{v, v})
+	}
+	return runs
+}
+
+// serializedSize returns the bytes c takes in the portable format
+func (c *container) serializedSize() int {
+	switch c.kind {
+	case bitmapContainer:
+		return containerBits / 8
+	case runContainer:
+		return 2 + 4*len(c.runs)
+	default:
+		return 2 * len(c.array)
+	}
+}
+
+type setOp int
+
+const (
+	opAnd setOp = iota
+	opOr
+	opXor
+	opAndNot
+)
+
+// combine returns the container of a op b, nil if empty.
+func combine(a, b *container, op setOp) *container {
+	if a.kind == arrayContainer && b.kind == arrayContainer {
+		return (&container{array: mergeArrays(a.array, b.array, op)}).normalize()
+	}
+	ab, bb := a.toBitField(), b.toBitField()
+	ret := New(containerBits)
+	switch op {
+	case opAnd:
+		ret.AndOf(ab, bb)
+	case opOr:
+		ret.OrOf(ab, bb)
+	case opXor:
+		ret.XorOf(ab, bb)
+	default:
+		ret.AndNotOf(ab, bb)
+	}
+	return containerOf(ret)
+}
+
+// mergeArrays does op on two sorted arrays
+func mergeArrays(a, b []uint16, op setOp) []uint16 {
+	ret := make([]uint16, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i] < b[j]):
+			if op != opAnd {
+				ret = append(ret, a[i])
+			}
+			i++
+		case i == len(a) || b[j] < a[i]:
+			if op == opOr || op == opXor {
+				ret = append(ret, b[j])
+			}
+			j++
+		default:
+			if op == opAnd || op == opOr {
+				ret = append(ret, a[i])
+			}
+			i++
+			j++
+		}
+	}
+	return ret
+}
+
+// Roaring is a compressed bitmap of uint32 values: the values are split by
+// their high 16 bits into containers that are stored as a sorted array, a
+// bitmap or a list of runs, whichever suits the values.
+//
+// It reads and writes the portable Roaring serialization format, shared by
+// the Roaring libraries of other languages.
+type Roaring struct {
+	keys       []uint16
+	containers []*container
+}
+
+// NewRoaring creates an empty Roaring bitmap.
+func NewRoaring() *Roaring {
+	return &Roaring{}
+}
+
+// find returns the index of the container of key, and if it exists.
+func (r *Roaring) find(key uint16) (int, bool) {
+	return slices.BinarySearch(r.keys, key)
+}
+
+// Add adds v to the bitmap.
+func (r *Roaring) Add(v uint32) *Roaring {
+	key, low := uint16(v>>16), uint16(v)
+	i, found := r.find(key)
+	if !found {
+		r.keys = slices.Insert(r.keys, i, key)
+		r.containers = slices.Insert(r.containers, i, &container{array: []uint16{low}})
+		return r
+	}
+	r.containers[i] = r.containers[i].add(low)
+	return r
+}
+
+// Remove removes v from the bitmap.
+func (r *Roaring) Remove(v uint32) *Roaring {
+	i, found := r.find(uint16(v >> 16))
+	if !found {
+		return r
+	}
+	if c := r.containers[i].remove(uint16(v)); c != nil {
+		r.containers[i] = c
+	} else {
+		r.keys = slices.Delete(r.keys, i, i+1)
+		r.containers = slices.Delete(r.containers, i, i+1)
+	}
+	return r
+}
+
+// Contains tells if v is in the bitmap.
+func (r *Roaring) Contains(v uint32) bool {
+	i, found := r.find(uint16(v >> 16))
+	return found && r.containers[i].contains(uint16(v))
+}
+
+// OnesCount returns the number of values in the bitmap.
+func (r *Roaring) OnesCount() int {
+	count := 0
+	for _, c := range r.containers {
+		count += c.card()
+	}
+	return count
+}
+
+// Ones returns an iterator over the values in increasing order.
+func (r *Roaring) Ones() iter.Seq[uint32] {
+	return func(yield func(uint32) bool) {
+		for i, c := range r.containers {
+			high := uint32(r.keys[i]) << 16
+			if !c.each(func(v uint16) bool { return yield(high | uint32(v)) }) {
+				return
+			}
+		}
+	}
+}
+
+// Clone returns a deep copy of the bitmap.
+func (r *Roaring) Clone() *Roaring {
+	ret := &Roaring{keys: slices.Clone(r.keys), containers: make([]*container, len(r.containers))}
+	for i, c := range r.containers {
+		ret.containers[i] = c.clone()
+	}
+	return ret
+}
+
+// Equal tells if the two bitmaps hold the same values.
+func (r *Roaring) Equal(other *Roaring) bool {
+	if !slices.Equal(r.keys, other.keys) {
+		return false
+	}
+	for i, c := range r.containers {
+		if !c.equal(other.containers[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// op merges the containers of r and other by key
+func (r *Roaring) op(other *Roaring, op setOp) *Roaring {
+	ret := NewRoaring()
+	add := func(key uint16, c *container) {
+		if c != nil {
+			ret.keys = append(ret.keys, key)
+			ret.containers = append(ret.containers, c)
+		}
+	}
+	i, j := 0, 0
+	for i < len(r.keys) || j < len(other.keys) {
+		switch {
+		case j == len(other.keys) || (i < len(r.keys) && r.keys[i] < other.keys[j]):
+			if op != opAnd {
+				add(r.keys[i], r.containers[i].clone())
+			}
+			i++
+		case i == len(r.keys) || other.keys[j] < r.keys[i]:
+			if op == opOr || op == opXor {
+				add(other.keys[j], other.containers[j].clone())
+			}
+			j++
+		default:
+			add(r.keys[i], combine(r.containers[i], other.containers[j], op))
+			i++
+			j++
+		}
+	}
+	return ret
+}
+
+// And returns the intersection of r and other as a new bitmap.
+func (r *Roaring) And(other *Roaring) *Roaring {
+	return r.op(other, opAnd)
+}
+
+// Or returns the union of r and other as a new bitmap.
+func (r *Roaring) Or(other *Roaring) *Roaring {
+	return r.op(other, opOr)
+}
+
+// Xor returns the symmetric difference of r and other as a new bitmap.
+func (r *Roaring) Xor(other *Roaring) *Roaring {
+	return r.op(other, opXor)
+}
+
+// AndNot returns the values of r not in other as a new bitmap.
+func (r *Roaring) AndNot(other *Roaring) *Roaring {
+	return r.op(other, opAndNot)
+}
+
+// RunOptimize converts each container to run-length encoding where that is
+// smaller, and back where it is not.
+func (r *Roaring) RunOptimize() *Roaring {
+	for i, c := range r.containers {
+		runs := c.runsOf()
+		if 2+4*len(runs) < plainSize(c.card()) {
+			r.containers[i] = &container{kind: runContainer, runs: slices.Clone(runs), n: c.card()}
+		} else if c.kind == runContainer {
+			r.containers[i] = containerOf(c.toBitField())
+		}
+	}
+	return r
+}
+
+// plainSize is the serialized size of a non-run container of cardinality card
+func plainSize(card int) int {
+	if card > arrayMaxCard {
+		return containerBits / 8
+	}
+	return 2 * card
+}
+
+// RoaringFromBitField creates a Roaring bitmap of the positions of the set
+// bits of bf. Returns ErrOutOfRange if bf is longer than 1<<32 bits.
+func RoaringFromBitField(bf *BitField) (*Roaring, error) {
+	const words = containerBits / 64
+	if uint64(bf.len) > 1<<32 {
+		return nil, ErrOutOfRange
+	}
+	ret := NewRoaring()
+	for lo := 0; lo < len(bf.data); lo += words {
+		part := New(containerBits)
+		copy(part.data, bf.data[lo:min(lo+words, len(bf.data))])
+		if c := containerOf(part); c != nil {
+			ret.keys = append(ret.keys, uint16(lo/words))
+			ret.containers = append(ret.containers, c)
+		}
+	}
+	return ret, nil
+}
+
+// BitField returns the bitmap as a BitField with the values as positions of
+// the set bits. Its length is the largest value plus one. Panics with
+// ErrOutOfRange if that exceeds the largest int.
+func (r *Roaring) BitField() *BitField {
+	const words = containerBits / 64
+	if len(r.keys) == 0 {
+		return New(0)
+	}
+	last := r.containers[len(r.containers)-1]
+	max := 0
+	last.each(func(v uint16) bool {
+		max = int(v)
+		return true
+	})
+	length := uint64(r.keys[len(r.keys)-1])<<16 + uint64(max) + 1
+	if length > math.MaxInt {
+		panic(ErrOutOfRange)
+	}
+	ret := New(int(length))
+	for i, c := range r.containers {
+		lo := int(r.keys[i]) * words
+		copy(ret.data[lo:], c.toBitField().data[:words])
+	}
+	return ret
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, writing the portable
+// Roaring serialization format.
+func (r *Roaring) MarshalBinary() ([]byte, error) {
+	size := len(r.keys)
+	hasRun := slices.ContainsFunc(r.containers, func(c *container) bool {
+		return c.kind == runContainer
+	})
+	var b []byte
+	if hasRun {
+		b = binary.LittleEndian.AppendUint32(b, serialCookie|uint32(size-1)<<16)
+		flags := make([]byte, (size+7)/8)
+		for i, c := range r.containers {
+			if c.kind == runContainer {
+				flags[i/8] |= 1 << uint(i%8)
+			}
+		}
+		b = append(b, flags...)
+	} else {
+		b = binary.LittleEndian.AppendUint32(b, serialCookieNoRun)
+		b = binary.LittleEndian.AppendUint32(b, uint32(size))
+	}
+	for i, c := range r.containers {
+		b = binary.LittleEndian.AppendUint16(b, r.keys[i])
+		b = binary.LittleEndian.AppendUint16(b, uint16(c.card()-1))
+	}
+	if !hasRun || size >= noOffsetThreshold {
+		offset := len(b) + 4*size
+		for _, c := range r.containers {
+			b = binary.LittleEndian.AppendUint32(b, uint32(offset))
+			offset += c.serializedSize()
+		}
+	}
+	for _, c := range r.containers {
+		switch c.kind {
+		case bitmapContainer:
+			for _, w := range c.bitmap.data[:containerBits/64] {
+				b = binary.LittleEndian.AppendUint64(b, uint64(w))
+			}
+		case runContainer:
+			b = binary.LittleEndian.AppendUint16(b, uint16(len(c.runs)))
+			for _, run := range c.runs {
+				b = binary.LittleEndian.AppendUint16(b, run.start)
+				b = binary.LittleEndian.AppendUint16(b, run.last-run.start)
+			}
+		default:
+			for _, v := range c.array {
+				b = binary.LittleEndian.AppendUint16(b, v)
+			}
+		}
+	}
+	return b, nil
+}
+
+// roaringReader reads little-endian values, remembering the first error
+type roaringReader struct {
+	data []byte
+	pos  int
+	bad  bool
+}
+
+func (rr *roaringReader) next(n int) []byte {
+	if rr.bad || len(rr.data)-rr.pos < n {
+		rr.bad = true
+		return make([]byte, n)
+	}
+	rr.pos += n
+	return rr.data[rr.pos-n : rr.pos]
+}
+
+func (rr *roaringReader) uint16() uint16 {
+	return binary.LittleEndian.Uint16(rr.next(2))
+}
+
+func (rr *roaringReader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(rr.next(4))
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, reading the
+// portable Roaring serialization format. Returns ErrBadFormat if data is
+// malformed.
+func (r *Roaring) UnmarshalBinary(data []byte) error {
+	rr := &roaringReader{data: data}
+	var size int
+	var runFlags []byte
+	hasOffsets := true
+	switch cookie := rr.uint32(); {
+	case cookie&0xffff == serialCookie:
+		size = int(cookie>>16) + 1
+		runFlags = rr.next((size + 7) / 8)
+		hasOffsets = size >= noOffsetThreshold
+	case cookie == serialCookieNoRun:
+		size = int(rr.uint32())
+	default:
+		return ErrBadFormat
+	}
+	if size > math.MaxUint16+1 || rr.bad {
+		return ErrBadFormat
+	}
+	keys := make([]uint16, size)
+	cards := make([]int, size)
+	for i := range keys {
+		keys[i] = rr.uint16()
+		cards[i] = int(rr.uint16()) + 1
+		if i > 0 && keys[i] <= keys[i-1] {
+			return ErrBadFormat
+		}
+	}
+	if hasOffsets {
+		rr.next(4 * size)
+	}
+	containers := make([]*container, size)
+	for i := range containers {
+		var c *container
+		switch {
+		case runFlags != nil && runFlags[i/8]&(1<<uint(i%8)) != 0:
+			c = &container{kind: runContainer, runs: make([]run16, rr.uint16())}
+			next := 0
+			for k := range c.runs {
+				start, length := rr.uint16(), rr.uint16()
+				if int(start) < next || int(start)+int(length) > math.MaxUint16 {
+					return ErrBadFormat
+				}
+				c.runs[k] = run16{start, start + length}
+				next = int(start) + int(length) + 2
+			}
+			c.n = runsCard(c.runs)
+		case cards[i] > arrayMaxCard:
+			c = &container{kind: bitmapContainer, bitmap: New(containerBits)}
+			for k := 0; k < containerBits/64; k++ {
+				c.bitmap.data[k] = BitField64(binary.LittleEndian.Uint64(rr.next(8)))
+			}
+			c.n = c.bitmap.OnesCount()
+		default:
+			c = &container{array: make([]uint16, cards[i])}
+			for k := range c.array {
+				c.array[k] = rr.uint16()
+				if k > 0 && c.array[k] <= c.array[k-1] {
+					return ErrBadFormat
+				}
+			}
+		}
+		if rr.bad || c.card() != cards[i] {
+			return ErrBadFormat
+		}
+		containers[i] = c
+	}
+	if rr.pos != len(data) {
+		return ErrBadFormat
+	}
+	r.keys, r.containers = keys, containers
+	return nil
+}
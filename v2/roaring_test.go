@@ -0,0 +1,143 @@
+package bitfield_test
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+// randomRoaring returns a bitmap and the sorted values in it, covering array,
+// bitmap and run containers
+func randomRoaring(r *rand.Rand) (*Roaring, []uint32) {
+	ra := NewRoaring()
+	set := map[uint32]bool{}
+	add := func(v uint32) {
+		ra.Add(v)
+		set[v] = true
+	}
+	for i := 0; i < 1000; i++ { // sparse
+		add(r.Uint32() >> 4)
+	}
+	for i := 0; i < 6000; i++ { // dense
+		add(3<<16 | uint32(r.Intn(1<<16)))
+	}
+	for v := uint32(5 << 16); v < 5<<16+uint32(r.Intn(10000))+10; v++ { // runs
+		add(v)
+	}
+	values := make([]uint32, 0, len(set))
+	for v := range set {
+		values = append(values, v)
+	}
+	slices.Sort(values)
+	return ra, values
+}
+
+func TestRoaring(t *testing.T) {
+	ra := NewRoaring().Add(1).Add(1 << 20).Add(1<<32 - 1)
+	assert(t, ra.OnesCount(), 3)
+	assert(t, ra.Contains(1<<20), true)
+	assert(t, ra.Contains(2), false)
+	assert(t, fmt.Sprint(slices.Collect(ra.Ones())), "[1 1048576 4294967295]")
+	ra.Remove(1 << 20).Remove(7)
+	assert(t, ra.OnesCount(), 2)
+	assert(t, ra.Contains(1<<20), false)
+
+	// array to bitmap and back
+	ra = NewRoaring()
+	for v := uint32(0); v < 10000; v += 2 {
+		ra.Add(v)
+	}
+	assert(t, ra.OnesCount(), 5000)
+	for v := uint32(0); v < 4000; v += 2 {
+		ra.Remove(v)
+	}
+	assert(t, ra.OnesCount(), 3000)
+	assert(t, ra.Contains(4000), true)
+	assert(t, ra.Contains(3998), false)
+	ra.Add(4000).Remove(3998)
+	assert(t, ra.OnesCount(), 3000)
+	assert(t, NewRoaring().Add(1).Add(2).Equal(NewRoaring().Add(1).Add(3)), false)
+
+	r := rand.New(rand.NewSource(1))
+	ra, values := randomRoaring(r)
+	assert(t, ra.OnesCount(), len(values))
+	assert(t, slices.Equal(slices.Collect(ra.Ones()), values), true)
+	opt := ra.Clone().RunOptimize()
+	assert(t, opt.Equal(ra), true)
+	assert(t, slices.Equal(slices.Collect(opt.Ones()), values), true)
+	for _, v := range values[:100] {
+		assert(t, opt.Contains(v), true)
+		opt.Remove(v)
+		assert(t, opt.Contains(v), false)
+	}
+	assert(t, opt.OnesCount(), len(values)-100)
+	assert(t, ra.OnesCount(), len(values))
+}
+
+func TestRoaringOps(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	a, _ := randomRoaring(r)
+	b, _ := randomRoaring(r)
+	b.RunOptimize()
+	bfa, bfb := a.BitField(), b.BitField()
+	n := max(bfa.Len(), bfb.Len())
+	bfa, bfb = bfa.Resize(n), bfb.Resize(n)
+	check := func(got *Roaring, want *BitField) {
+		t.Helper()
+		w, _ := RoaringFromBitField(want)
+		assert(t, got.Equal(w), true)
+		assert(t, got.OnesCount(), want.OnesCount())
+	}
+	check(a.And(b), bfa.And(bfb))
+	check(a.Or(b), bfa.Or(bfb))
+	check(a.Xor(b), bfa.Xor(bfb))
+	check(a.AndNot(b), bfa.AndNot(bfb))
+	check(b.AndNot(a), bfb.AndNot(bfa))
+	assert(t, a.Xor(a).OnesCount(), 0)
+}
+
+func TestRoaringBitField(t *testing.T) {
+	bf := New(200000).Set(0, 5, 70000, 199999)
+	ra, err := RoaringFromBitField(bf)
+	assert(t, err, nil)
+	assert(t, fmt.Sprint(slices.Collect(ra.Ones())), "[0 5 70000 199999]")
+	assert(t, ra.BitField().Equal(bf), true)
+	assert(t, NewRoaring().BitField().Len(), 0)
+	assert(t, NewRoaring().Add(3).BitField().String(), "0001")
+}
+
+func TestRoaringBinary(t *testing.T) {
+	b, _ := NewRoaring().Add(1).Add(2).Add(3).MarshalBinary()
+	assert(t, fmt.Sprintf("% x", b), "3a 30 00 00 01 00 00 00 00 00 02 00 10 00 00 00 01 00 02 00 03 00")
+	ra := NewRoaring()
+	for v := uint32(1); v <= 10; v++ {
+		ra.Add(v)
+	}
+	b, _ = ra.RunOptimize().MarshalBinary()
+	assert(t, fmt.Sprintf("% x", b), "3b 30 00 00 01 00 00 09 00 01 00 01 00 09 00")
+
+	r := rand.New(rand.NewSource(3))
+	for _, optimize := range []bool{false, true} {
+		ra, values := randomRoaring(r)
+		if optimize {
+			ra.RunOptimize()
+		}
+		b, err := ra.MarshalBinary()
+		assert(t, err, nil)
+		got := NewRoaring()
+		assert(t, got.UnmarshalBinary(b), nil)
+		assert(t, slices.Equal(slices.Collect(got.Ones()), values), true)
+		for _, n := range []int{0, 3, 7, len(b) / 2, len(b) - 1} {
+			assert(t, NewRoaring().UnmarshalBinary(b[:n]), ErrBadFormat)
+		}
+	}
+	assert(t, NewRoaring().UnmarshalBinary([]byte{1, 2, 3, 4, 0, 0, 0, 0}), ErrBadFormat)
+	// unsorted array
+	bad := []byte{0x3a, 0x30, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0, 0x10, 0, 0, 0, 2, 0, 1, 0}
+	assert(t, NewRoaring().UnmarshalBinary(bad), ErrBadFormat)
+	b, _ = NewRoaring().MarshalBinary()
+	assert(t, NewRoaring().Add(1).UnmarshalBinary(b), nil)
+}
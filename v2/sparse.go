@@ -0,0 +1,184 @@
+package bitfield
+
+import (
+	"iter"
+	"slices"
+)
+
+// sparsePageBits is the number of bits in a page of Sparse: 4 Ki bits, 512
+// bytes.
+const sparsePageBits = 1 << 12
+
+// sparsePage is a page of Sparse with the number of bits set in it
+type sparsePage struct {
+	bits  *BitField // sparsePageBits long, mutable
+	count int
+}
+
+// Sparse is a bitmap addressed by uint64 positions. It is made of fixed-size
+// BitField pages of 4 Ki bits found by a map. Pages are allocated on the
+// first write to them and freed when they become empty, so memory is
+// proportional to the number of pages touched.
+type Sparse struct {
+	pages map[uint64]*sparsePage
+	keys  []uint64 // indexes of the pages, sorted
+}
+
+// NewSparse creates an empty sparse bitmap.
+func NewSparse() *Sparse {
+	return &Sparse{pages: map[uint64]*sparsePage{}}
+}
+
+// locate returns the index of the page holding pos and the offset in it
+func (s *Sparse) locate(pos uint64) (uint64, int) {
+	return pos / sparsePageBits, int(pos % sparsePageBits)
+}
+
+// Set sets the bit at position pos. Mutable.
+func (s *Sparse) Set(pos uint64) *Sparse {
+	if s.pages == nil {
+		s.pages = map[uint64]*sparsePage{}
+	}
+	index, offset := s.locate(pos)
+	p, found := s.pages[index]
+	if !found {
+		p = &sparsePage{bits: New(sparsePageBits).Mut()}
+		s.pages[index] = p
+		i, _ := slices.BinarySearch(s.keys, index)
+		s.keys = slices.Insert(s.keys, i, index)
+	}
+	if !p.bits.Get(offset) {
+		p.bits.Set(offset)
+		p.count++
+	}
+	return s
+}
+
+// Clear clears the bit at position pos. Mutable.
+func (s *Sparse) Clear(pos uint64) *Sparse {
+	index, offset := s.locate(pos)
+	p, found := s.pages[index]
+	if !found || !p.bits.Get(offset) {
+		return s
+	}
+	p.bits.Clear(offset)
+	if p.count--; p.count == 0 {
+		delete(s.pages, index)
+		i, _ := slices.BinarySearch(s.keys, index)
+		s.keys = slices.Delete(s.keys, i, i+1)
+	}
+	return s
+}
+
+// Get returns the bit (as a boolean) at position pos
+func (s *Sparse) Get(pos uint64) bool {
+	index, offset := s.locate(pos)
+	p, found := s.pages[index]
+	return found && p.bits.Get(offset)
+}
+
+// OnesCount returns the number of bits set
+func (s *Sparse) OnesCount() int {
+	count := 0
+	for _, p := range s.pages {
+		count += p.count
+	}
+	return count
+}
+
+// Pages returns the number of pages allocated.
+func (s *Sparse) Pages() int {
+	return len(s.pages)
+}
+
+// NextSet returns the position of the first set bit at or after from.
+// Returns false if there is none.
+func (s *Sparse) NextSet(from uint64) (uint64, bool) {
+	index, offset := s.locate(from)
+	i, found := slices.BinarySearch(s.keys, index)
+	if found {
+		if v, ok := s.pages[index].bits.NextSet(offset); ok {
+			return index*sparsePageBits + uint64(v), true
+		}
+		i++
+	}
+	if i == len(s.keys) {
+		return 0, false
+	}
+	v, _ := s.pages[s.keys[i]].bits.NextSet(0)
+	return s.keys[i]*sparsePageBits + uint64(v), true
+}
+
+// Ones returns an iterator over the positions of the set bits in
+// increasing order.
+func (s *Sparse) Ones() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for _, index := range s.keys {
+			base := index * sparsePageBits
+			for v := range s.pages[index].bits.Ones() {
+				if !yield(base + uint64(v)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Clone returns a copy of s.
+func (s *Sparse) Clone() *Sparse {
+	ret := &Sparse{pages: make(map[uint64]*sparsePage, len(s.pages)), keys: slices.Clone(s.keys)}
+	for index, p := range s.pages {
+		ret.pages[index] = &sparsePage{bits: p.bits.Clone().Mut(), count: p.count}
+	}
+	return ret
+}
+
+// Equal tells if the two bitmaps have the same bits set.
+func (s *Sparse) Equal(other *Sparse) bool {
+	if len(s.pages) != len(other.pages) {
+		return false
+	}
+	for index, p := range s.pages {
+		o, found := other.pages[index]
+		if !found || p.count != o.count || !p.bits.Equal(o.bits) {
+			return false
+		}
+	}
+	return true
+}
+
+// Or returns the union of s and other as a new bitmap.
+func (s *Sparse) Or(other *Sparse) *Sparse {
+	ret := other.Clone()
+	for index, p := range s.pages {
+		if o, found := ret.pages[index]; found {
+			o.bits.Or(p.bits)
+			o.count = o.bits.OnesCount()
+			continue
+		}
+		ret.pages[index] = &sparsePage{bits: p.bits.Clone().Mut(), count: p.count}
+		i, _ := slices.BinarySearch(ret.keys, index)
+		ret.keys = slices.Insert(ret.keys, i, index)
+	}
+	return ret
+}
+
+// And returns the intersection of s and other as a new bitmap.
+func (s *Sparse) And(other *Sparse) *Sparse {
+	if len(other.pages) < len(s.pages) {
+		s, other = other, s
+	}
+	ret := NewSparse()
+	for _, index := range s.keys {
+		o, found := other.pages[index]
+		if !found {
+			continue
+		}
+		bits := s.pages[index].bits.Clone().Mut().And(o.bits)
+		if count := bits.OnesCount(); count > 0 {
+			ret.pages[index] = &sparsePage{bits: bits, count: count}
+			ret.keys = append(ret.keys, index)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,108 @@
+package bitfield_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestSparse(t *testing.T) {
+	s := NewSparse()
+	assert(t, s.Get(math.MaxUint64), false)
+	s.Set(0).Set(5).Set(1 << 40).Set(math.MaxUint64).Set(5)
+	assert(t, s.OnesCount(), 4)
+	assert(t, s.Pages(), 3)
+	assert(t, s.Get(1<<40), true)
+	assert(t, s.Get(1<<40+1), false)
+	assert(t, s.Get(math.MaxUint64), true)
+	assert(t, fmt.Sprint(slices.Collect(s.Ones())), "[0 5 1099511627776 18446744073709551615]")
+
+	pos, ok := s.NextSet(6)
+	assert(t, pos, uint64(1<<40))
+	assert(t, ok, true)
+	pos, ok = s.NextSet(5)
+	assert(t, pos, uint64(5))
+	pos, ok = s.NextSet(1<<40 + 1)
+	assert(t, pos, uint64(math.MaxUint64))
+	_, ok = NewSparse().NextSet(0)
+	assert(t, ok, false)
+
+	s.Clear(1 << 40).Clear(1 << 40).Clear(7).Clear(1 << 50)
+	assert(t, s.OnesCount(), 3)
+	assert(t, s.Pages(), 2) // the empty page is freed
+	s.Clear(0)
+	assert(t, s.Pages(), 2)
+	s.Clear(5).Clear(math.MaxUint64)
+	assert(t, s.Pages(), 0)
+	_, ok = s.NextSet(0)
+	assert(t, ok, false)
+}
+
+func TestSparseOps(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	random := func() (*Sparse, map[uint64]bool) {
+		s, m := NewSparse(), map[uint64]bool{}
+		for i := 0; i < 2000; i++ {
+			v := uint64(r.Intn(1 << 20))
+			if i%2 == 0 {
+				v = r.Uint64()
+			}
+			s.Set(v)
+			m[v] = true
+		}
+		return s, m
+	}
+	a, ma := random()
+	b, mb := random()
+	or, and := a.Or(b), a.And(b)
+	orCount, andCount := 0, 0
+	for v := range ma {
+		assert(t, or.Get(v), true)
+		assert(t, and.Get(v), mb[v])
+	}
+	for v := range mb {
+		assert(t, or.Get(v), true)
+		if ma[v] {
+			andCount++
+		} else {
+			orCount++
+		}
+	}
+	assert(t, or.OnesCount(), len(ma)+orCount)
+	assert(t, and.OnesCount(), andCount)
+	assert(t, a.And(a).Equal(a), true)
+	assert(t, a.Or(NewSparse()).Equal(a), true)
+	assert(t, a.And(NewSparse()).Pages(), 0)
+
+	c := a.Clone()
+	assert(t, c.Equal(a), true)
+	for v := range c.Ones() {
+		c.Clear(v)
+		break
+	}
+	assert(t, c.Equal(a), false)
+	assert(t, c.OnesCount(), a.OnesCount()-1)
+	assert(t, slices.IsSorted(slices.Collect(or.Ones())), true)
+
+	// NextSet walks the bits in order, also after pages were added
+	var want []uint64
+	for v := range or.Ones() {
+		want = append(want, v)
+	}
+	or.Set(7).Set(math.MaxUint64 - 1)
+	want = append(want, 7, math.MaxUint64-1)
+	slices.Sort(want)
+	want = slices.Compact(want)
+	var got []uint64
+	for v, ok := or.NextSet(0); ok; v, ok = or.NextSet(v + 1) {
+		got = append(got, v)
+		if v == math.MaxUint64 {
+			break
+		}
+	}
+	assert(t, fmt.Sprint(got), fmt.Sprint(want))
+}
@@ -0,0 +1,210 @@
+package bitfield
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Parse parses the output of String(): '0' and '1' characters, position 0
+// first. An optional "0b" prefix is accepted, as well as '_' and ' ' as
+// separators anywhere after it. Returns ErrBadFormat for any other input.
+func Parse(s string) (*BitField, error) {
+	s = strings.TrimPrefix(s, "0b")
+	n := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '0', '1':
+			n++
+		case '_', ' ':
+		default:
+			return nil, ErrBadFormat
+		}
+	}
+	ret := New(n)
+	pos := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '1':
+			ret.data[pos/64] = ret.data[pos/64].Set(pos % 64)
+			fallthrough
+		case '0':
+			pos++
+		}
+	}
+	return ret, nil
+}
+
+// MarshalText implements encoding.TextMarshaler: the text is String().
+func (bf *BitField) MarshalText() ([]byte, error) {
+	return []byte(bf.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting whatever Parse
+// does. It replaces the content and length of bf.
+func (bf *BitField) UnmarshalText(text []byte) error {
+	ret, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	bf.data, bf.len = ret.data, ret.len
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler: the bitfield is encoded as a JSON
+// string holding String(). Use Encoded for other representations.
+func (bf *BitField) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bf.String())
+}
+
+// maxPositionsGrowth is the length an array of positions may always grow a
+// bitfield to, see UnmarshalJSON.
+const maxPositionsGrowth = 1 << 20
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts all representations
+// written by Encoded: a string as returned by String(), a base64 string of
+// MarshalBinary() or an array of set positions. The array does not carry the
+// length: bf is cleared and grows to hold the highest position if needed.
+//
+// As a short array can name a huge position, it may only grow bf to 1<<20
+// bits or 64 bits per byte of data, whichever is more. Larger positions
+// return ErrOutOfRange unless bf is already long enough to hold them: set
+// the length of the receiver to accept them.
+func (bf *BitField) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case string(data) == "null":
+		return nil
+
+	case len(data) > 0 && data[0] == '[':
+		// int64 rather than int, so that positions beyond a 32-bit int
+		// are out of range instead of malformed
+		var positions []int64
+		if err := json.Unmarshal(data, &positions); err != nil {
+			return ErrBadFormat
+		}
+		length := bf.len
+		limit := int64(max(bf.len, maxPositionsGrowth, 64*len(data)))
+		for _, p := range positions {
+			if p < 0 {
+				return ErrBadFormat
+			}
+			if p >= limit {
+				return ErrOutOfRange
+			}
+			length = max(length, int(p)+1)
+		}
+		ret := New(length)
+		for _, p := range positions {
+			ret.data[p/64] = ret.data[p/64].Set(int(p % 64))
+		}
+		bf.data, bf.len = ret.data, ret.len
+		return nil
+
+	default:
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return ErrBadFormat
+		}
+		if err := bf.UnmarshalText([]byte(s)); err == nil {
+			return nil
+		}
+		bin, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return ErrBadFormat
+		}
+		return bf.UnmarshalBinary(bin)
+	}
+}
+
+// Format selects the text and JSON representation used by Encoded.
+type Format int
+
+const (
+	// FormatBits is the output of String(), e.g. "0110".
+	FormatBits Format = iota
+	// FormatBase64 is the standard base64 encoding of MarshalBinary().
+	FormatBase64
+	// FormatPositions lists the positions of the set bits: a JSON array,
+	// or comma separated decimals as text, e.g. "1,2". Len() is not kept.
+	FormatPositions
+)
+
+// Encoded pairs a BitField with the representation used for its text and
+// JSON encoding. Decoding JSON recognizes all representations; decoding text
+// expects Format.
+//
+//	type Config struct {
+//		Mask bitfield.Encoded `json:"mask"`
+//	}
+type Encoded struct {
+	*BitField
+	Format Format
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (e Encoded) MarshalText() ([]byte, error) {
+	if e.BitField == nil {
+		return []byte{}, nil
+	}
+	switch e.Format {
+	case FormatBase64:
+		bin, _ := e.BitField.MarshalBinary()
+		return base64.StdEncoding.AppendEncode(nil, bin), nil
+	case FormatPositions:
+		var b []byte
+		for p := range e.Ones() {
+			if len(b) > 0 {
+				b = append(b, ',')
+			}
+			b = strconv.AppendInt(b, int64(p), 10)
+		}
+		return b, nil
+	default:
+		return e.BitField.MarshalText()
+	}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (e *Encoded) UnmarshalText(text []byte) error {
+	if e.BitField == nil {
+		e.BitField = New(0)
+	}
+	switch e.Format {
+	case FormatBase64:
+		bin, err := base64.StdEncoding.DecodeString(string(text))
+		if err != nil {
+			return ErrBadFormat
+		}
+		return e.BitField.UnmarshalBinary(bin)
+	case FormatPositions:
+		return e.BitField.UnmarshalJSON([]byte("[" + string(text) + "]"))
+	default:
+		return e.BitField.UnmarshalText(text)
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e Encoded) MarshalJSON() ([]byte, error) {
+	if e.BitField == nil {
+		return []byte("null"), nil
+	}
+	switch e.Format {
+	case FormatPositions:
+		text, _ := e.MarshalText()
+		return []byte("[" + string(text) + "]"), nil
+	default:
+		text, _ := e.MarshalText()
+		return json.Marshal(string(text))
+	}
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *Encoded) UnmarshalJSON(data []byte) error {
+	if e.BitField == nil {
+		e.BitField = New(0)
+	}
+	return e.BitField.UnmarshalJSON(data)
+}
@@ -0,0 +1,117 @@
+package bitfield_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/bukshee/bitfield/v2"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"", ""},
+		{"0b", ""},
+		{"0110", "0110"},
+		{"0b0110_0001", "01100001"},
+		{"1 1 0", "110"},
+	}
+	for _, tt := range tests {
+		a, err := Parse(tt.in)
+		assert(t, err, nil)
+		assert(t, a.String(), tt.out)
+	}
+	a := New(130).Set(0, 64, -1)
+	b, err := Parse(a.String())
+	assert(t, err, nil)
+	assert(t, b.Equal(a), true)
+
+	for _, in := range []string{"012", "0x01", "b01", "1,0"} {
+		_, err := Parse(in)
+		assert(t, errors.Is(err, ErrBadFormat), true)
+	}
+
+	text, _ := a.MarshalText()
+	c := New(0)
+	assert(t, c.UnmarshalText(text), nil)
+	assert(t, c.Equal(a), true)
+	assert(t, errors.Is(c.UnmarshalText([]byte("2")), ErrBadFormat), true)
+}
+
+func TestJSON(t *testing.T) {
+	a := New(70).Set(1, 2, 69)
+	for _, f := range []Format{FormatBits, FormatBase64, FormatPositions} {
+		data, err := json.Marshal(Encoded{a, f})
+		assert(t, err, nil)
+
+		var b *BitField
+		assert(t, json.Unmarshal(data, &b), nil)
+		var e Encoded
+		assert(t, json.Unmarshal(data, &e), nil)
+		assert(t, b.Equal(a), true)
+		assert(t, e.Equal(a), true)
+
+		text, err := Encoded{a, f}.MarshalText()
+		assert(t, err, nil)
+		e = Encoded{Format: f}
+		assert(t, e.UnmarshalText(text), nil)
+		assert(t, e.Equal(a), true)
+	}
+
+	data, _ := json.Marshal(a)
+	assert(t, string(data), `"`+a.String()+`"`)
+	data, _ = json.Marshal(Encoded{a, FormatPositions})
+	assert(t, string(data), "[1,2,69]")
+	text, _ := Encoded{a, FormatPositions}.MarshalText()
+	assert(t, string(text), "1,2,69")
+
+	// positions keep the length of the receiver if it is longer
+	b := New(100).Set(50)
+	assert(t, json.Unmarshal([]byte("[3]"), b), nil)
+	assert(t, b.Len(), 100)
+	assert(t, b.String(), New(100).Set(3).String())
+	assert(t, json.Unmarshal([]byte("null"), b), nil)
+	assert(t, b.Len(), 100)
+
+	// huge positions are only accepted up to the length of the receiver
+	for _, in := range []string{`[268435455]`, `[1099511627775]`, `[9223372036854775807]`} {
+		assert(t, New(0).UnmarshalJSON([]byte(in)), ErrOutOfRange)
+	}
+	e := Encoded{Format: FormatPositions}
+	assert(t, e.UnmarshalText([]byte("1099511627775")), ErrOutOfRange)
+	assert(t, json.Unmarshal([]byte("[1048575]"), b), nil)
+	assert(t, b.Len(), 1<<20)
+	b = New(1 << 22)
+	assert(t, json.Unmarshal([]byte("[4194303]"), b), nil)
+	assert(t, b.Get(1<<22-1), true)
+
+	for _, in := range []string{`[-1]`, `["a"]`, `3`, `"abc!"`, `"QUJD"`} {
+		err := New(0).UnmarshalJSON([]byte(in))
+		assert(t, errors.Is(err, ErrBadFormat), true)
+	}
+	e = Encoded{Format: FormatBase64}
+	assert(t, errors.Is(e.UnmarshalText([]byte("!")), ErrBadFormat), true)
+
+	data, _ = json.Marshal(Encoded{})
+	assert(t, string(data), "null")
+	text, _ = Encoded{}.MarshalText()
+	assert(t, string(text), "")
+}
+
+func ExampleEncoded() {
+	type config struct {
+		Mask Encoded `json:"mask"`
+	}
+	data, _ := json.Marshal(config{Encoded{NewBitField(8).Set(1, 6), FormatPositions}})
+	fmt.Println(string(data))
+
+	var c config
+	json.Unmarshal([]byte(`{"mask":"0b0100_0010"}`), &c)
+	fmt.Println(c.Mask)
+	// Output: {"mask":[1,6]}
+	// 01000010
+}
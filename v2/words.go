@@ -0,0 +1,100 @@
+package bitfield
+
+import "math/bits"
+
+// Word-level helpers working directly on the underlying []BitField64.
+// Positions here are plain offsets into the words: no modulo treatment and
+// no bounds checks beyond what the slices do.
+
+// getBits returns width bits (0<=width<=64) starting at pos as the low bits of
+// the result. Bits beyond the stored words read as zero.
+func (bf *BitField) getBits(pos, width int) uint64 {
+	const n = 64
+	if width == 0 {
+		return 0
+	}
+	index, offset := pos/n, uint(pos%n)
+	v := uint64(bf.data[index]) >> offset
+	if offset+uint(width) > n && index+1 < len(bf.data) {
+		v |= uint64(bf.data[index+1]) << (n - offset)
+	}
+	if width < n {
+		v &= 1<<uint(width) - 1
+	}
+	return v
+}
+
+// setBits writes the low width bits (0<=width<=64) of v starting at pos.
+func (bf *BitField) setBits(pos, width int, v uint64) {
+	const n = 64
+	if width == 0 {
+		return
+	}
+	index, offset := pos/n, uint(pos%n)
+	mask := ^uint64(0) >> uint(n-width)
+	v &= mask
+	bf.data[index] = bf.data[index]&^BitField64(mask<<offset) | BitField64(v<<offset)
+	if offset+uint(width) > n {
+		rest := n - offset
+		bf.data[index+1] = bf.data[index+1]&^BitField64(mask>>rest) | BitField64(v>>rest)
+	}
+}
+
+// copyBits copies count bits from src at srcPos to dst at dstPos.
+// The two ranges must not overlap if src and dst are the same.
+func copyBits(dst *BitField, dstPos int, src *BitField, srcPos, count int) {
+	const n = 64
+	for count > 0 {
+		w := min(count, n)
+		dst.setBits(dstPos, w, src.getBits(srcPos, w))
+		dstPos, srcPos, count = dstPos+w, srcPos+w, count-w
+	}
+}
+
+// reverseBits reverses the order of the bits in [lo, hi) in-place.
+func (bf *BitField) reverseBits(lo, hi int) {
+	const n = 64
+	for hi-lo > 1 {
+		w := min(n, (hi-lo)/2)
+		a := bits.Reverse64(bf.getBits(lo, w)) >> uint(n-w)
+		b := bits.Reverse64(bf.getBits(hi-w, w)) >> uint(n-w)
+		bf.setBits(lo, w, b)
+		bf.setBits(hi-w, w, a)
+		lo, hi = lo+w, hi-w
+	}
+}
+
+// shiftWords writes src shifted by count bits into dst, both must have the
+// same length. dst may be the same slice as src. If count is positive it
+// shifts towards higher bit positions. Bits shifted out are discarded, zeroes
+// are shifted in.
+func shiftWords(dst, src []BitField64, count int) {
+	const n = 64
+	words := len(dst)
+	if count >= 0 {
+		ix, delta := count/n, uint(count%n)
+		// going downwards: when aliased, src[i-ix-1:i-ix+1] are still intact
+		for i := words - 1; i >= 0; i-- {
+			var w BitField64
+			if j := i - ix; j >= 0 {
+				w = src[j] << delta
+				if delta > 0 && j > 0 {
+					w |= src[j-1] >> (n - delta)
+				}
+			}
+			dst[i] = w
+		}
+		return
+	}
+	ix, delta := -count/n, uint(-count%n)
+	for i := 0; i < words; i++ {
+		var w BitField64
+		if j := i + ix; j < words {
+			w = src[j] >> delta
+			if delta > 0 && j+1 < words {
+				w |= src[j+1] << (n - delta)
+			}
+		}
+		dst[i] = w
+	}
+}